@@ -11,8 +11,14 @@ import (
 // analyticsSelectData stores the state of an Analytics SELECT query as it is built
 type analyticsSelectData struct {
 	PlaceholderFormat PlaceholderFormat
+	PlaceholderOffset int
+	MaxQueryLength    int
+	FormatTimes       bool
+	StrictPaging      bool
 	RunWith           QueryRunner
+	ExtraArgs         []any
 	Prefixes          []N1qlizer
+	Hints             []string
 	Options           []string
 	Columns           []N1qlizer
 	From              N1qlizer
@@ -22,7 +28,10 @@ type analyticsSelectData struct {
 	HavingParts       []N1qlizer
 	OrderByParts      []N1qlizer
 	Limit             string
+	LimitParam        N1qlizer
+	DefaultLimit      string
 	Offset            string
+	OffsetParam       N1qlizer
 	LetsClause        map[string]N1qlizer // Maps variable names to their values
 	Window            string
 	Suffixes          []N1qlizer
@@ -34,7 +43,14 @@ func (d *analyticsSelectData) ToN1ql() (sqlStr string, args []any, err error) {
 		return
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sqlStr)
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, sqlStr, d.PlaceholderOffset)
+	if err != nil {
+		return
+	}
+
+	args = append(args, d.ExtraArgs...)
+
+	err = checkQueryLength(sqlStr, d.MaxQueryLength)
 	return
 }
 
@@ -44,6 +60,12 @@ func (d *analyticsSelectData) toN1qlRaw() (sqlStr string, args []any, err error)
 		return
 	}
 
+	if d.StrictPaging && (len(d.Offset) > 0 || d.OffsetParam != nil) &&
+		len(d.Limit) == 0 && d.LimitParam == nil && len(d.DefaultLimit) == 0 {
+		err = fmt.Errorf("select statements with OFFSET must have a LIMIT")
+		return
+	}
+
 	sql := &bytes.Buffer{}
 
 	if len(d.Prefixes) > 0 {
@@ -57,6 +79,10 @@ func (d *analyticsSelectData) toN1qlRaw() (sqlStr string, args []any, err error)
 
 	sql.WriteString("SELECT ")
 
+	if err = writeHints(sql, d.Hints); err != nil {
+		return
+	}
+
 	if len(d.Options) > 0 {
 		sql.WriteString(strings.Join(d.Options, " "))
 		sql.WriteString(" ")
@@ -116,11 +142,15 @@ func (d *analyticsSelectData) toN1qlRaw() (sqlStr string, args []any, err error)
 	}
 
 	if len(d.WhereParts) > 0 {
-		sql.WriteString(" WHERE ")
-		args, err = buildClauses(d.WhereParts, sql, " AND ", args)
+		where := &bytes.Buffer{}
+		args, err = buildClauses(d.WhereParts, where, " AND ", args)
 		if err != nil {
 			return
 		}
+		if where.Len() > 0 {
+			sql.WriteString(" WHERE ")
+			sql.Write(where.Bytes())
+		}
 	}
 
 	if len(d.GroupBys) > 0 {
@@ -129,11 +159,15 @@ func (d *analyticsSelectData) toN1qlRaw() (sqlStr string, args []any, err error)
 	}
 
 	if len(d.HavingParts) > 0 {
-		sql.WriteString(" HAVING ")
-		args, err = buildClauses(d.HavingParts, sql, " AND ", args)
+		having := &bytes.Buffer{}
+		args, err = buildClauses(d.HavingParts, having, " AND ", args)
 		if err != nil {
 			return
 		}
+		if having.Len() > 0 {
+			sql.WriteString(" HAVING ")
+			sql.Write(having.Bytes())
+		}
 	}
 
 	if len(d.OrderByParts) > 0 {
@@ -149,12 +183,27 @@ func (d *analyticsSelectData) toN1qlRaw() (sqlStr string, args []any, err error)
 		sql.WriteString(d.Window)
 	}
 
-	if len(d.Limit) > 0 {
+	if d.LimitParam != nil {
+		sql.WriteString(" LIMIT ")
+		args, err = buildClauses([]N1qlizer{d.LimitParam}, sql, "", args)
+		if err != nil {
+			return
+		}
+	} else if len(d.Limit) > 0 {
 		sql.WriteString(" LIMIT ")
 		sql.WriteString(d.Limit)
+	} else if len(d.DefaultLimit) > 0 {
+		sql.WriteString(" LIMIT ")
+		sql.WriteString(d.DefaultLimit)
 	}
 
-	if len(d.Offset) > 0 {
+	if d.OffsetParam != nil {
+		sql.WriteString(" OFFSET ")
+		args, err = buildClauses([]N1qlizer{d.OffsetParam}, sql, "", args)
+		if err != nil {
+			return
+		}
+	} else if len(d.Offset) > 0 {
 		sql.WriteString(" OFFSET ")
 		sql.WriteString(d.Offset)
 	}
@@ -167,6 +216,10 @@ func (d *analyticsSelectData) toN1qlRaw() (sqlStr string, args []any, err error)
 		}
 	}
 
+	if d.FormatTimes {
+		args = formatTimeArgs(args)
+	}
+
 	sqlStr = sql.String()
 	return
 }
@@ -184,6 +237,45 @@ func (b AnalyticsSelectBuilder) PlaceholderFormat(f PlaceholderFormat) Analytics
 	return Set[AnalyticsSelectBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
 }
 
+// StartPlaceholdersAt makes numbered placeholder formats (e.g. Dollar) start
+// numbering at n+1, for queries appended to N1QL that already uses $1..$n.
+// Formats without numbered placeholders ignore it.
+func (b AnalyticsSelectBuilder) StartPlaceholdersAt(n int) AnalyticsSelectBuilder {
+	return Set[AnalyticsSelectBuilder, int](b, "PlaceholderOffset", n)
+}
+
+func (b AnalyticsSelectBuilder) withPlaceholderOffset(n int) N1qlizer {
+	return b.StartPlaceholdersAt(n)
+}
+
+// MaxQueryLength makes ToN1ql fail if the generated N1QL is longer than n
+// bytes, catching runaway dynamic queries. Zero, the default, means unlimited.
+func (b AnalyticsSelectBuilder) MaxQueryLength(n int) AnalyticsSelectBuilder {
+	return Set[AnalyticsSelectBuilder, int](b, "MaxQueryLength", n)
+}
+
+// FormatTimes makes the query bind time.Time and *time.Time args as RFC 3339
+// strings, the format N1QL date functions and comparisons expect. Zero times
+// and nil pointers are bound as NULL.
+func (b AnalyticsSelectBuilder) FormatTimes() AnalyticsSelectBuilder {
+	return Set[AnalyticsSelectBuilder, bool](b, "FormatTimes", true)
+}
+
+// StrictPaging makes ToN1ql fail if the query has an OFFSET but no LIMIT,
+// which is usually a paging bug and is rejected by some N1QL versions.
+func (b AnalyticsSelectBuilder) StrictPaging() AnalyticsSelectBuilder {
+	return Set[AnalyticsSelectBuilder, bool](b, "StrictPaging", true)
+}
+
+// ExtraArgs appends args to the end of the args returned by ToN1ql and Args
+// without adding placeholders, for driver integrations that take trailing
+// parameters after the query's own. The query's placeholders and args no
+// longer line up one to one, so only pass the result to a runner that
+// expects the extra args.
+func (b AnalyticsSelectBuilder) ExtraArgs(args ...any) AnalyticsSelectBuilder {
+	return Extend[AnalyticsSelectBuilder, any](b, "ExtraArgs", args)
+}
+
 // RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
 func (b AnalyticsSelectBuilder) RunWith(runner QueryRunner) AnalyticsSelectBuilder {
 	return Set[AnalyticsSelectBuilder, QueryRunner](b, "RunWith", runner)
@@ -225,6 +317,13 @@ func (b AnalyticsSelectBuilder) ToN1ql() (string, []any, error) {
 	return data.ToN1ql()
 }
 
+// ToN1qlNamed builds the query into a N1QL string using named parameters
+// ($p1, $p2, ...) and returns the bound args keyed by parameter name
+// (p1, p2, ...).
+func (b AnalyticsSelectBuilder) ToN1qlNamed() (string, map[string]any, error) {
+	return toN1qlNamed(b.PlaceholderFormat(Named))
+}
+
 // MustN1ql builds the query into a N1QL string and bound args.
 //
 // MustN1ql panics if there are any errors.
@@ -236,7 +335,48 @@ func (b AnalyticsSelectBuilder) MustN1ql() (string, []any) {
 	return sql, args
 }
 
-// Columns adds result columns to the query.
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(AnalyticsSelectBuilder) AnalyticsSelectBuilder.
+func (b AnalyticsSelectBuilder) Apply(fns ...func(AnalyticsSelectBuilder) AnalyticsSelectBuilder) AnalyticsSelectBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b AnalyticsSelectBuilder) ApplyIf(cond bool, fn func(AnalyticsSelectBuilder) AnalyticsSelectBuilder) AnalyticsSelectBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Args returns the args the query binds, without finalizing its N1QL.
+func (b AnalyticsSelectBuilder) Args() ([]any, error) {
+	data := GetStruct(b).(analyticsSelectData)
+	_, args, err := data.toN1qlRaw()
+	return append(args, data.ExtraArgs...), err
+}
+
+// Prefix adds an expression to the beginning of the query
+func (b AnalyticsSelectBuilder) Prefix(sql string, args ...any) AnalyticsSelectBuilder {
+	return b.PrefixExpr(Expr(sql, args...))
+}
+
+// PrefixExpr adds an expression to the very beginning of the query
+func (b AnalyticsSelectBuilder) PrefixExpr(expr N1qlizer) AnalyticsSelectBuilder {
+	return Append[AnalyticsSelectBuilder, N1qlizer](b, "Prefixes", expr)
+}
+
+// Hint adds optimizer hints to the query, rendered as a comment right after
+// SELECT, e.g. .Hint("INDEX(u idx_age)") renders "SELECT /*+ INDEX(u idx_age) */ ...".
+func (b AnalyticsSelectBuilder) Hint(hints ...string) AnalyticsSelectBuilder {
+	return Extend[AnalyticsSelectBuilder, string](b, "Hints", hints)
+}
+
+// Columns sets the result columns of the query, replacing any set before.
 func (b AnalyticsSelectBuilder) Columns(columns ...string) AnalyticsSelectBuilder {
 	parts := make([]N1qlizer, 0, len(columns))
 	for _, str := range columns {
@@ -245,13 +385,32 @@ func (b AnalyticsSelectBuilder) Columns(columns ...string) AnalyticsSelectBuilde
 	return Set[AnalyticsSelectBuilder, []N1qlizer](b, "Columns", parts)
 }
 
+// addColumns adds result columns after those already set, including ones
+// set with Columns.
+func (b AnalyticsSelectBuilder) addColumns(parts ...N1qlizer) AnalyticsSelectBuilder {
+	data := GetStruct(b).(analyticsSelectData)
+	columns := append(append([]N1qlizer{}, data.Columns...), parts...)
+	return Set[AnalyticsSelectBuilder, []N1qlizer](b, "Columns", columns)
+}
+
 // Column adds a result column to the query.
 // Unlike Columns, Column accepts args which will be bound to placeholders in
 // the column string, for example:
 //
 //	.Column("IF(n_subscribers > ?, ?, ?)", 100, "HIGH", "LOW")
 func (b AnalyticsSelectBuilder) Column(column any, args ...any) AnalyticsSelectBuilder {
-	return Append[AnalyticsSelectBuilder, N1qlizer](b, "Columns", Expr(column, args...))
+	return b.addColumns(Expr(column, args...))
+}
+
+// ColumnAs adds an aliased result column to the query. expr is either a
+// string, which may contain placeholders bound to args, or a N1qlizer, for
+// example:
+//
+//	.ColumnAs("IFMISSING(nickname, ?)", "name", "anonymous")
+//
+// renders "(IFMISSING(nickname, ?)) AS name".
+func (b AnalyticsSelectBuilder) ColumnAs(expr any, alias string, args ...any) AnalyticsSelectBuilder {
+	return b.addColumns(Alias(Expr(expr, args...), alias))
 }
 
 // From sets the FROM clause of the query.
@@ -259,6 +418,26 @@ func (b AnalyticsSelectBuilder) From(from string) AnalyticsSelectBuilder {
 	return Set[AnalyticsSelectBuilder, N1qlizer](b, "From", newPart(from))
 }
 
+// JoinClause adds a join clause to the query.
+func (b AnalyticsSelectBuilder) JoinClause(join string, args ...any) AnalyticsSelectBuilder {
+	return Append[AnalyticsSelectBuilder, N1qlizer](b, "Joins", Expr(join, args...))
+}
+
+// Join adds a JOIN clause to the query.
+func (b AnalyticsSelectBuilder) Join(join string, rest ...any) AnalyticsSelectBuilder {
+	return b.JoinClause("JOIN "+join, rest...)
+}
+
+// LeftJoin adds a LEFT JOIN clause to the query.
+func (b AnalyticsSelectBuilder) LeftJoin(join string, rest ...any) AnalyticsSelectBuilder {
+	return b.JoinClause("LEFT JOIN "+join, rest...)
+}
+
+// InnerJoin adds an INNER JOIN clause to the query.
+func (b AnalyticsSelectBuilder) InnerJoin(join string, rest ...any) AnalyticsSelectBuilder {
+	return b.JoinClause("INNER JOIN "+join, rest...)
+}
+
 // Let adds a LET binding variable to the query.
 func (b AnalyticsSelectBuilder) Let(variable string, value any) AnalyticsSelectBuilder {
 	data := GetStruct(b).(analyticsSelectData)
@@ -285,8 +464,20 @@ func (b AnalyticsSelectBuilder) Window(windowClause string) AnalyticsSelectBuild
 }
 
 // Where adds an expression to the WHERE clause of the query.
+// A nil pred adds nothing, and a bool pred adds the constant condition 1=1
+// (true) or 1=0 (false).
 func (b AnalyticsSelectBuilder) Where(pred any, args ...any) AnalyticsSelectBuilder {
-	return Append[AnalyticsSelectBuilder, N1qlizer](b, "WhereParts", Expr(pred, args...))
+	if pred == nil {
+		return b
+	}
+	return Append[AnalyticsSelectBuilder, N1qlizer](b, "WhereParts", predicate(pred, args...))
+}
+
+// WhereIn adds a "column IN (...)" expression to the WHERE clause of the
+// query. valuesOrSubquery may be a SelectBuilder, a slice of values or a
+// single value; see In.
+func (b AnalyticsSelectBuilder) WhereIn(column string, valuesOrSubquery any) AnalyticsSelectBuilder {
+	return b.Where(In(column, valuesOrSubquery))
 }
 
 // GroupBy adds GROUP BY expressions to the query.
@@ -294,8 +485,33 @@ func (b AnalyticsSelectBuilder) GroupBy(groupBys ...string) AnalyticsSelectBuild
 	return Set[AnalyticsSelectBuilder, []string](b, "GroupBys", groupBys)
 }
 
+// GroupByAs adds aliased GROUP BY expressions to the query. Keys are the
+// grouping expressions and values their aliases, rendered as "expr AS alias"
+// in sorted key order, so that the groups can be referenced by alias in
+// HAVING and the result columns, for example:
+//
+//	.GroupByAs(map[string]string{"u.address.country": "country"}).Having("country <> ?", "NL")
+func (b AnalyticsSelectBuilder) GroupByAs(groupBys map[string]string) AnalyticsSelectBuilder {
+	exprs := make([]string, 0, len(groupBys))
+	for e := range groupBys {
+		exprs = append(exprs, e)
+	}
+	sort.Strings(exprs)
+
+	data := GetStruct(b).(analyticsSelectData)
+	parts := append([]string{}, data.GroupBys...)
+	for _, e := range exprs {
+		parts = append(parts, fmt.Sprintf("%s AS %s", e, groupBys[e]))
+	}
+	return Set[AnalyticsSelectBuilder, []string](b, "GroupBys", parts)
+}
+
 // Having adds an expression to the HAVING clause of the query.
+// A nil pred adds nothing.
 func (b AnalyticsSelectBuilder) Having(pred any, rest ...any) AnalyticsSelectBuilder {
+	if pred == nil {
+		return b
+	}
 	return Append[AnalyticsSelectBuilder, N1qlizer](b, "HavingParts", Expr(pred, rest...))
 }
 
@@ -308,16 +524,53 @@ func (b AnalyticsSelectBuilder) OrderBy(orderBys ...string) AnalyticsSelectBuild
 	return Set[AnalyticsSelectBuilder, []N1qlizer](b, "OrderByParts", parts)
 }
 
+// OrderByDir adds an ORDER BY expression sorting by field in direction dir to
+// the query, after those added before. See SelectBuilder.OrderByDir.
+func (b AnalyticsSelectBuilder) OrderByDir(field string, dir SortDir) AnalyticsSelectBuilder {
+	data := GetStruct(b).(analyticsSelectData)
+	parts := append(append([]N1qlizer{}, data.OrderByParts...), orderByDir{field: field, dir: dir})
+	return Set[AnalyticsSelectBuilder, []N1qlizer](b, "OrderByParts", parts)
+}
+
 // Limit sets a LIMIT clause on the query.
 func (b AnalyticsSelectBuilder) Limit(limit uint64) AnalyticsSelectBuilder {
 	return Set[AnalyticsSelectBuilder, string](b, "Limit", fmt.Sprintf("%d", limit))
 }
 
+// DefaultLimit sets a LIMIT applied only if Limit is not set, as a guard
+// against unbounded scans.
+func (b AnalyticsSelectBuilder) DefaultLimit(limit uint64) AnalyticsSelectBuilder {
+	return Set[AnalyticsSelectBuilder, string](b, "DefaultLimit", fmt.Sprintf("%d", limit))
+}
+
 // Offset sets an OFFSET clause on the query.
 func (b AnalyticsSelectBuilder) Offset(offset uint64) AnalyticsSelectBuilder {
 	return Set[AnalyticsSelectBuilder, string](b, "Offset", fmt.Sprintf("%d", offset))
 }
 
+// LimitParam sets a LIMIT clause on the query with the limit bound as an arg
+// rather than inlined, so that queries differing only in their limit share a
+// cached plan. It takes precedence over Limit.
+func (b AnalyticsSelectBuilder) LimitParam(limit uint64) AnalyticsSelectBuilder {
+	return Set[AnalyticsSelectBuilder, N1qlizer](b, "LimitParam", Expr("?", limit))
+}
+
+// OffsetParam sets an OFFSET clause on the query with the offset bound as an
+// arg. It takes precedence over Offset.
+func (b AnalyticsSelectBuilder) OffsetParam(offset uint64) AnalyticsSelectBuilder {
+	return Set[AnalyticsSelectBuilder, N1qlizer](b, "OffsetParam", Expr("?", offset))
+}
+
+// Suffix adds an expression to the end of the query
+func (b AnalyticsSelectBuilder) Suffix(sql string, args ...any) AnalyticsSelectBuilder {
+	return b.SuffixExpr(Expr(sql, args...))
+}
+
+// SuffixExpr adds an expression to the end of the query
+func (b AnalyticsSelectBuilder) SuffixExpr(expr N1qlizer) AnalyticsSelectBuilder {
+	return Append[AnalyticsSelectBuilder, N1qlizer](b, "Suffixes", expr)
+}
+
 // AnalyticsSelect creates a new AnalyticsSelectBuilder for Couchbase Analytics queries.
 func AnalyticsSelect(columns ...string) AnalyticsSelectBuilder {
 	sb := StatementBuilderType(EmptyBuilder)
@@ -0,0 +1,54 @@
+package n1qlizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// placeholderOffsetter is implemented by builders that can start numbering
+// their placeholders after an offset (see StartPlaceholdersAt).
+type placeholderOffsetter interface {
+	withPlaceholderOffset(n int) N1qlizer
+}
+
+// batch is a list of statements rendered as a single script.
+type batch []N1qlizer
+
+// Batch joins several statements into a single script, separated by ";\n",
+// e.g. for migrations. The args of all statements are concatenated, and
+// numbered placeholders (e.g. Dollar) continue across statements, so that
+//
+//	Batch(
+//		Update("users").Set("active", false).Where("age > ?", 90),
+//		Delete("sessions").Where("userId = ?", 1),
+//	)
+//
+// renders "UPDATE users SET active = $1 WHERE age > $2;\nDELETE FROM sessions WHERE userId = $3"
+// when both builders use Dollar.
+func Batch(stmts ...N1qlizer) N1qlizer {
+	return batch(stmts)
+}
+
+func (b batch) ToN1ql() (string, []any, error) {
+	if len(b) == 0 {
+		return "", nil, fmt.Errorf("batch must have at least one statement")
+	}
+
+	sqls := make([]string, 0, len(b))
+	var args []any
+	for i, stmt := range b {
+		if o, ok := stmt.(placeholderOffsetter); ok && len(args) > 0 {
+			stmt = o.withPlaceholderOffset(len(args))
+		}
+
+		sql, stmtArgs, err := stmt.ToN1ql()
+		if err != nil {
+			return "", nil, fmt.Errorf("batch statement %d: %v", i+1, err)
+		}
+
+		sqls = append(sqls, sql)
+		args = append(args, stmtArgs...)
+	}
+
+	return strings.Join(sqls, ";\n"), args, nil
+}
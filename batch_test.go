@@ -0,0 +1,50 @@
+package n1qlizer
+
+import (
+	"testing"
+)
+
+// TestBatch tests joining statements into a script
+func TestBatch(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := Batch(
+		sb.Update("users").Set("active", false).Where("age > ?", 90),
+		sb.Delete("sessions").Where("userId = ?", 1),
+		Expr("CREATE INDEX idx_age ON users(age)"),
+		sb.Select("*").From("users").Where(Eq{"active": true}),
+	).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build batch: %v", err)
+	}
+
+	expected := "UPDATE users SET active = $1 WHERE age > $2;\n" +
+		"DELETE FROM sessions WHERE userId = $3;\n" +
+		"CREATE INDEX idx_age ON users(age);\n" +
+		"SELECT * FROM users WHERE active = $4"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{false, 90, 1, true}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+
+	sql, _, err = Batch(Select("*").From("a").Where("x = ?", 1), Select("*").From("b").Where("y = ?", 2)).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build batch: %v", err)
+	}
+
+	if sql != "SELECT * FROM a WHERE x = ?;\nSELECT * FROM b WHERE y = ?" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	if _, _, err := Batch(Select("*").From("a"), Delete("")).ToN1ql(); err == nil {
+		t.Error("Expected error for invalid statement, got nil")
+	}
+
+	if _, _, err := Batch().ToN1ql(); err == nil {
+		t.Error("Expected error for empty batch, got nil")
+	}
+}
@@ -170,3 +170,73 @@ func TestSetInvalidNil(t *testing.T) {
 		t.Errorf("expected panic, didn't")
 	}
 }
+
+func TestApply(t *testing.T) {
+	active := func(b SelectBuilder) SelectBuilder {
+		return b.Where(Eq{"active": true})
+	}
+	adults := func(b SelectBuilder) SelectBuilder {
+		return b.Where("age >= ?", 18)
+	}
+
+	sql, args, err := Select("*").From("users").Apply(active, adults).OrderBy("name").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users WHERE active = ? AND age >= ? ORDER BY name"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 2 || args[0] != true || args[1] != 18 {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	sql, _, err = Delete("sessions").Apply(func(b DeleteBuilder) DeleteBuilder {
+		return b.Where("expired = ?", true)
+	}).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "DELETE FROM sessions WHERE expired = ?" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+}
+
+func TestApplyIf(t *testing.T) {
+	build := func(status string) (string, []any) {
+		return Select("*").
+			From("users").
+			Where("age >= ?", 18).
+			ApplyIf(status != "", func(b SelectBuilder) SelectBuilder {
+				return b.Where(Eq{"status": status})
+			}).
+			MustN1ql()
+	}
+
+	sql, args := build("active")
+	if sql != "SELECT * FROM users WHERE age >= ? AND status = ?" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+	if len(args) != 2 || args[1] != "active" {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	sql, args = build("")
+	if sql != "SELECT * FROM users WHERE age >= ?" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+	if len(args) != 1 {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	sql, _ = Update("users").Set("active", false).
+		ApplyIf(true, func(b UpdateBuilder) UpdateBuilder { return b.Where("id = ?", 1) }).
+		ApplyIf(false, func(b UpdateBuilder) UpdateBuilder { return b.Limit(1) }).
+		MustN1ql()
+	if sql != "UPDATE users SET active = ? WHERE id = ?" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+}
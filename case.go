@@ -7,7 +7,7 @@ import (
 // CaseBuilder builds SQL CASE expressions.
 type CaseBuilder interface {
 	N1qlizer
-	When(condition interface{}, value interface{}) CaseBuilder
+	When(condition interface{}, value interface{}, condArgs ...interface{}) CaseBuilder
 	Else(value interface{}) CaseBuilder
 }
 
@@ -31,13 +31,18 @@ func NewCaseBuilderWithValue(value interface{}) CaseBuilder {
 
 type whenPart struct {
 	condition interface{}
+	condArgs  []interface{}
 	value     interface{}
 }
 
 // When adds a WHEN ... THEN ... clause to the CASE expression.
-func (b *searchedCaseBuilder) When(condition interface{}, value interface{}) CaseBuilder {
+// condArgs are bound to placeholders in a string condition, for example:
+//
+//	.When("score > ?", "High", 100)
+func (b *searchedCaseBuilder) When(condition interface{}, value interface{}, condArgs ...interface{}) CaseBuilder {
 	b.whenParts = append(b.whenParts, whenPart{
 		condition: condition,
+		condArgs:  condArgs,
 		value:     value,
 	})
 	return b
@@ -66,54 +71,34 @@ func (b *searchedCaseBuilder) ToN1ql() (sql string, args []interface{}, err erro
 			buf.WriteString(csql)
 			args = append(args, cargs...)
 		case string:
-			// Treat strings as SQL with placeholders
-			buf.WriteString(c)
-			if v, ok := w.value.(string); ok {
-				args = append(args, v)
-				buf.WriteString(" THEN ?")
-				continue
+			// Treat strings as SQL conditions, binding any condition args
+			if len(w.condArgs) == 0 {
+				buf.WriteString(c)
+				break
 			}
+			csql, cargs, err := Expr(c, w.condArgs...).ToN1ql()
+			if err != nil {
+				return "", nil, err
+			}
+			buf.WriteString(csql)
+			args = append(args, cargs...)
 		default:
 			buf.WriteString("?")
 			args = append(args, w.condition)
 		}
 
 		buf.WriteString(" THEN ")
-		switch v := w.value.(type) {
-		case N1qlizer:
-			vsql, vargs, err := v.ToN1ql()
-			if err != nil {
-				return "", nil, err
-			}
-			buf.WriteString(vsql)
-			args = append(args, vargs...)
-		case string:
-			// String values should be treated as placeholders
-			buf.WriteString("?")
-			args = append(args, v)
-		default:
-			buf.WriteString("?")
-			args = append(args, w.value)
+		args, err = writeCaseValue(buf, w.value, args)
+		if err != nil {
+			return "", nil, err
 		}
 	}
 
 	if b.elsePart != nil {
 		buf.WriteString(" ELSE ")
-		switch e := b.elsePart.(type) {
-		case N1qlizer:
-			esql, eargs, err := e.ToN1ql()
-			if err != nil {
-				return "", nil, err
-			}
-			buf.WriteString(esql)
-			args = append(args, eargs...)
-		case string:
-			// String values should be treated as placeholders
-			buf.WriteString("?")
-			args = append(args, e)
-		default:
-			buf.WriteString("?")
-			args = append(args, b.elsePart)
+		args, err = writeCaseValue(buf, b.elsePart, args)
+		if err != nil {
+			return "", nil, err
 		}
 	}
 
@@ -121,6 +106,22 @@ func (b *searchedCaseBuilder) ToN1ql() (sql string, args []interface{}, err erro
 	return buf.String(), args, nil
 }
 
+// writeCaseValue writes a THEN or ELSE result of a CASE expression. N1qlizer
+// values are embedded, any other value is bound to a placeholder.
+func writeCaseValue(buf *bytes.Buffer, value interface{}, args []interface{}) ([]interface{}, error) {
+	if v, ok := value.(N1qlizer); ok {
+		vsql, vargs, err := v.ToN1ql()
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(vsql)
+		return append(args, vargs...), nil
+	}
+
+	buf.WriteString("?")
+	return append(args, value), nil
+}
+
 // simpleCaseBuilder builds SQL CASE expressions with an initial value
 // e.g. "CASE a WHEN 'foo' THEN 1 WHEN 'bar' THEN 2 ELSE 3 END"
 type simpleCaseBuilder struct {
@@ -130,9 +131,13 @@ type simpleCaseBuilder struct {
 }
 
 // When adds a WHEN ... THEN ... clause to the CASE expression.
-func (b *simpleCaseBuilder) When(condition interface{}, value interface{}) CaseBuilder {
+// condArgs are bound to placeholders in a string condition, for example:
+//
+//	.When("score > ?", "High", 100)
+func (b *simpleCaseBuilder) When(condition interface{}, value interface{}, condArgs ...interface{}) CaseBuilder {
 	b.whenParts = append(b.whenParts, whenPart{
 		condition: condition,
+		condArgs:  condArgs,
 		value:     value,
 	})
 	return b
@@ -176,6 +181,16 @@ func (b *simpleCaseBuilder) ToN1ql() (sql string, args []interface{}, err error)
 			buf.WriteString(csql)
 			args = append(args, cargs...)
 		case string:
+			if len(w.condArgs) > 0 {
+				// With args, the condition is an expression with placeholders
+				csql, cargs, err := Expr(c, w.condArgs...).ToN1ql()
+				if err != nil {
+					return "", nil, err
+				}
+				buf.WriteString(csql)
+				args = append(args, cargs...)
+				break
+			}
 			// For WHEN conditions in simple CASE, treat as values
 			buf.WriteString("?")
 			args = append(args, c)
@@ -185,41 +200,17 @@ func (b *simpleCaseBuilder) ToN1ql() (sql string, args []interface{}, err error)
 		}
 
 		buf.WriteString(" THEN ")
-		switch v := w.value.(type) {
-		case N1qlizer:
-			vsql, vargs, err := v.ToN1ql()
-			if err != nil {
-				return "", nil, err
-			}
-			buf.WriteString(vsql)
-			args = append(args, vargs...)
-		case string:
-			// String values should be treated as placeholders
-			buf.WriteString("?")
-			args = append(args, v)
-		default:
-			buf.WriteString("?")
-			args = append(args, w.value)
+		args, err = writeCaseValue(buf, w.value, args)
+		if err != nil {
+			return "", nil, err
 		}
 	}
 
 	if b.elsePart != nil {
 		buf.WriteString(" ELSE ")
-		switch e := b.elsePart.(type) {
-		case N1qlizer:
-			esql, eargs, err := e.ToN1ql()
-			if err != nil {
-				return "", nil, err
-			}
-			buf.WriteString(esql)
-			args = append(args, eargs...)
-		case string:
-			// String values should be treated as placeholders
-			buf.WriteString("?")
-			args = append(args, e)
-		default:
-			buf.WriteString("?")
-			args = append(args, b.elsePart)
+		args, err = writeCaseValue(buf, b.elsePart, args)
+		if err != nil {
+			return "", nil, err
 		}
 	}
 
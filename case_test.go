@@ -105,3 +105,102 @@ func TestCaseExpressions(t *testing.T) {
 		t.Logf("Case expression successfully generated: %s", sql)
 	})
 }
+
+// TestCaseStringConditions tests that string and bound conditions render a single THEN
+func TestCaseStringConditions(t *testing.T) {
+	t.Run("String condition", func(t *testing.T) {
+		sql, args, err := NewCaseBuilder().
+			When("status = 'active'", "Active").
+			Else(0).
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build CASE expression: %v", err)
+		}
+
+		if sql != "CASE WHEN status = 'active' THEN ? ELSE ? END" {
+			t.Errorf("Wrong SQL: %s", sql)
+		}
+
+		if len(args) != 2 || args[0] != "Active" || args[1] != 0 {
+			t.Errorf("Wrong args: %+v", args)
+		}
+	})
+
+	t.Run("Condition with placeholder", func(t *testing.T) {
+		sql, args, err := NewCaseBuilder().
+			When(Expr("status = ?", "A"), "Active").
+			When("score > 100", 42).
+			Else("Other").
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build CASE expression: %v", err)
+		}
+
+		if sql != "CASE WHEN status = ? THEN ? WHEN score > 100 THEN ? ELSE ? END" {
+			t.Errorf("Wrong SQL: %s", sql)
+		}
+
+		expected := []interface{}{"A", "Active", 42, "Other"}
+		if len(args) != len(expected) {
+			t.Fatalf("Wrong number of args: Expected %d, got %d", len(expected), len(args))
+		}
+
+		for i, arg := range args {
+			if arg != expected[i] {
+				t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, expected[i], arg)
+			}
+		}
+	})
+}
+
+// TestCaseConditionArgs tests binding args to WHEN conditions
+func TestCaseConditionArgs(t *testing.T) {
+	t.Run("String condition with args", func(t *testing.T) {
+		sql, args, err := NewCaseBuilder().
+			When("score > ? AND level = ?", "High", 100, "gold").
+			Else("Low").
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build CASE expression: %v", err)
+		}
+
+		if sql != "CASE WHEN score > ? AND level = ? THEN ? ELSE ? END" {
+			t.Errorf("Wrong SQL: %s", sql)
+		}
+
+		expected := []interface{}{100, "gold", "High", "Low"}
+		if len(args) != len(expected) {
+			t.Fatalf("Wrong number of args: Expected %d, got %d", len(expected), len(args))
+		}
+
+		for i, arg := range args {
+			if arg != expected[i] {
+				t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, expected[i], arg)
+			}
+		}
+	})
+
+	t.Run("Expr condition", func(t *testing.T) {
+		sql, args, err := NewCaseBuilder().
+			When(Expr("x > ?", 5), "big").
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build CASE expression: %v", err)
+		}
+
+		if sql != "CASE WHEN x > ? THEN ? END" {
+			t.Errorf("Wrong SQL: %s", sql)
+		}
+
+		if len(args) != 2 || args[0] != 5 || args[1] != "big" {
+			t.Errorf("Wrong args: %+v", args)
+		}
+	})
+
+	t.Run("Missing condition args", func(t *testing.T) {
+		_, _, err := NewCaseBuilder().When("x > ? AND y > ?", "big", 5).ToN1ql()
+		if err == nil {
+			t.Error("Expected error for missing condition args, got nil")
+		}
+	})
+}
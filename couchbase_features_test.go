@@ -244,6 +244,130 @@ func TestAnalyticsSupport(t *testing.T) {
 			t.Errorf("Wrong function: Expected 'ARRAY_AVG(prices)', got '%s'", sql)
 		}
 	})
+
+	t.Run("LimitParam and OffsetParam", func(t *testing.T) {
+		builder := AnalyticsSelect("*").
+			From("users").
+			Where("age > ?", 18).
+			Limit(100).
+			LimitParam(10).
+			OffsetParam(20)
+
+		sql, args, err := builder.ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build Analytics query: %v", err)
+		}
+
+		if sql != "SELECT * FROM users WHERE age > ? LIMIT ? OFFSET ?" {
+			t.Errorf("Wrong SQL: %s", sql)
+		}
+
+		expectedArgs := []interface{}{18, uint64(10), uint64(20)}
+		if !argsEqual(expectedArgs, args) {
+			t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+		}
+
+		sql, _, err = builder.PlaceholderFormat(Dollar).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build Analytics query: %v", err)
+		}
+
+		if sql != "SELECT * FROM users WHERE age > $1 LIMIT $2 OFFSET $3" {
+			t.Errorf("Wrong SQL: %s", sql)
+		}
+	})
+
+	t.Run("GroupByAs", func(t *testing.T) {
+		sql, args, err := AnalyticsSelect("country", "city", "COUNT(*) AS total").
+			From("users u").
+			GroupBy("u.status").
+			GroupByAs(map[string]string{"u.address.country": "country", "u.address.city": "city"}).
+			Having("country <> ?", "NL").
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build Analytics query: %v", err)
+		}
+
+		expected := "SELECT country, city, COUNT(*) AS total FROM users u GROUP BY u.status, u.address.city AS city, u.address.country AS country HAVING country <> ?"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+
+		if len(args) != 1 || args[0] != "NL" {
+			t.Errorf("Wrong args: %+v", args)
+		}
+	})
+
+	t.Run("Joins", func(t *testing.T) {
+		sql, args, err := AnalyticsSelect("c.name", "SUM(o.total) AS spent").
+			From("customers c").
+			Join("orders o ON o.custId = c.id AND o.status = ?", "paid").
+			LeftJoin("regions r ON r.id = c.regionId").
+			InnerJoin("segments s ON s.id = c.segmentId").
+			JoinClause("LEFT OUTER JOIN notes n ON n.custId = c.id").
+			Where("c.active = ?", true).
+			GroupBy("c.name").
+			PlaceholderFormat(Dollar).
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build Analytics query: %v", err)
+		}
+
+		expected := "SELECT c.name, SUM(o.total) AS spent FROM customers c " +
+			"JOIN orders o ON o.custId = c.id AND o.status = $1 " +
+			"LEFT JOIN regions r ON r.id = c.regionId " +
+			"INNER JOIN segments s ON s.id = c.segmentId " +
+			"LEFT OUTER JOIN notes n ON n.custId = c.id " +
+			"WHERE c.active = $2 GROUP BY c.name"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+
+		expectedArgs := []interface{}{"paid", true}
+		if !argsEqual(expectedArgs, args) {
+			t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+		}
+	})
+
+	t.Run("Prefix and Suffix", func(t *testing.T) {
+		sql, args, err := AnalyticsSelect("*").
+			Prefix("SET `compiler.parallelism` ?;", 4).
+			From("users").
+			Where("age > ?", 18).
+			Suffix("LIMIT ?", 5).
+			SuffixExpr(Expr("OFFSET ?", 10)).
+			PlaceholderFormat(Dollar).
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build Analytics query: %v", err)
+		}
+
+		expected := "SET `compiler.parallelism` $1; SELECT * FROM users WHERE age > $2 LIMIT $3 OFFSET $4"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+
+		expectedArgs := []interface{}{4, 18, 5, 10}
+		if !argsEqual(expectedArgs, args) {
+			t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+		}
+	})
+
+	t.Run("OrderByDir", func(t *testing.T) {
+		sql, _, err := AnalyticsSelect("*").From("users").OrderByDir("age", Desc).OrderByDir("name", Asc).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build Analytics query: %v", err)
+		}
+
+		expected := "SELECT * FROM users ORDER BY age DESC, name ASC"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+
+		if _, _, err := AnalyticsSelect("*").From("users").OrderByDir("age", SortDir("UP")).ToN1ql(); err == nil {
+			t.Error("Expected error for invalid sort direction, got nil")
+		}
+	})
 }
 
 // TestJSONSupport tests the JSON document support functions
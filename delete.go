@@ -7,15 +7,24 @@ import (
 
 // deleteData stores the state of a DELETE query as it is built
 type deleteData struct {
-	PlaceholderFormat PlaceholderFormat
-	RunWith           QueryRunner
-	Prefixes          []N1qlizer
-	From              string
-	WhereParts        []N1qlizer
-	UseKeys           string
-	Limit             string
-	Offset            string
-	Suffixes          []N1qlizer
+	PlaceholderFormat       PlaceholderFormat
+	PlaceholderOffset       int
+	MaxQueryLength          int
+	FormatTimes             bool
+	Strict                  bool
+	StrictPaging            bool
+	ExpandDefaultCollection bool
+	Namespace               string
+	RunWith                 QueryRunner
+	ExtraArgs               []any
+	QueryOptions            QueryOptions
+	Prefixes                []N1qlizer
+	From                    string
+	WhereParts              []N1qlizer
+	UseKeys                 N1qlizer
+	Limit                   string
+	Offset                  string
+	Suffixes                []N1qlizer
 }
 
 func (d *deleteData) ToN1ql() (sqlStr string, args []any, err error) {
@@ -24,7 +33,14 @@ func (d *deleteData) ToN1ql() (sqlStr string, args []any, err error) {
 		return
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sqlStr)
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, sqlStr, d.PlaceholderOffset)
+	if err != nil {
+		return
+	}
+
+	args = append(args, d.ExtraArgs...)
+
+	err = checkQueryLength(sqlStr, d.MaxQueryLength)
 	return
 }
 
@@ -34,6 +50,17 @@ func (d *deleteData) toN1qlRaw() (sqlStr string, args []any, err error) {
 		return
 	}
 
+	if d.Strict {
+		if err = checkStrict(d.strictParts()...); err != nil {
+			return
+		}
+	}
+
+	if d.StrictPaging && len(d.Offset) > 0 && len(d.Limit) == 0 {
+		err = fmt.Errorf("delete statements with OFFSET must have a LIMIT")
+		return
+	}
+
 	sql := &bytes.Buffer{}
 
 	if len(d.Prefixes) > 0 {
@@ -46,19 +73,30 @@ func (d *deleteData) toN1qlRaw() (sqlStr string, args []any, err error) {
 	}
 
 	sql.WriteString("DELETE FROM ")
-	sql.WriteString(d.From)
+	target := d.From
+	if d.ExpandDefaultCollection {
+		target = qualifyKeyspaces(target)
+	}
+	sql.WriteString(namespaceKeyspaces(target, d.Namespace))
 
-	if len(d.UseKeys) > 0 {
+	if d.UseKeys != nil {
 		sql.WriteString(" USE KEYS ")
-		sql.WriteString(d.UseKeys)
+		args, err = buildClauses([]N1qlizer{d.UseKeys}, sql, "", args)
+		if err != nil {
+			return
+		}
 	}
 
 	if len(d.WhereParts) > 0 {
-		sql.WriteString(" WHERE ")
-		args, err = buildClauses(d.WhereParts, sql, " AND ", args)
+		where := &bytes.Buffer{}
+		args, err = buildClauses(d.WhereParts, where, " AND ", args)
 		if err != nil {
 			return
 		}
+		if where.Len() > 0 {
+			sql.WriteString(" WHERE ")
+			sql.Write(where.Bytes())
+		}
 	}
 
 	if len(d.Limit) > 0 {
@@ -79,6 +117,10 @@ func (d *deleteData) toN1qlRaw() (sqlStr string, args []any, err error) {
 		}
 	}
 
+	if d.FormatTimes {
+		args = formatTimeArgs(args)
+	}
+
 	sqlStr = sql.String()
 	return
 }
@@ -96,18 +138,73 @@ func (b DeleteBuilder) PlaceholderFormat(f PlaceholderFormat) DeleteBuilder {
 	return Set[DeleteBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
 }
 
+// StartPlaceholdersAt makes numbered placeholder formats (e.g. Dollar) start
+// numbering at n+1, for queries appended to N1QL that already uses $1..$n.
+// Formats without numbered placeholders ignore it.
+func (b DeleteBuilder) StartPlaceholdersAt(n int) DeleteBuilder {
+	return Set[DeleteBuilder, int](b, "PlaceholderOffset", n)
+}
+
+func (b DeleteBuilder) withPlaceholderOffset(n int) N1qlizer {
+	return b.StartPlaceholdersAt(n)
+}
+
+// MaxQueryLength makes ToN1ql fail if the generated N1QL is longer than n
+// bytes, catching runaway dynamic queries. Zero, the default, means unlimited.
+func (b DeleteBuilder) MaxQueryLength(n int) DeleteBuilder {
+	return Set[DeleteBuilder, int](b, "MaxQueryLength", n)
+}
+
+// FormatTimes makes the query bind time.Time and *time.Time args as RFC 3339
+// strings, the format N1QL date functions and comparisons expect. Zero times
+// and nil pointers are bound as NULL.
+func (b DeleteBuilder) FormatTimes() DeleteBuilder {
+	return Set[DeleteBuilder, bool](b, "FormatTimes", true)
+}
+
+// StrictMode makes ToN1ql fail if the N1QL of an expression passed as a
+// string looks like it has unbound user input concatenated into it: a ";", a
+// "--" or "/*" comment marker, or an unterminated quote outside of quoted
+// literals. It is a defense-in-depth check; values should still be bound
+// with placeholders.
+func (b DeleteBuilder) StrictMode() DeleteBuilder {
+	return Set[DeleteBuilder, bool](b, "Strict", true)
+}
+
+// StrictPaging makes ToN1ql fail if the query has an OFFSET but no LIMIT,
+// which is usually a paging bug and is rejected by some N1QL versions.
+func (b DeleteBuilder) StrictPaging() DeleteBuilder {
+	return Set[DeleteBuilder, bool](b, "StrictPaging", true)
+}
+
+// ExtraArgs appends args to the end of the args returned by ToN1ql and Args
+// without adding placeholders, for driver integrations that take trailing
+// parameters after the query's own. The query's placeholders and args no
+// longer line up one to one, so only pass the result to a runner that
+// expects the extra args.
+func (b DeleteBuilder) ExtraArgs(args ...any) DeleteBuilder {
+	return Extend[DeleteBuilder, any](b, "ExtraArgs", args)
+}
+
 // RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
 func (b DeleteBuilder) RunWith(runner QueryRunner) DeleteBuilder {
 	return Set[DeleteBuilder, QueryRunner](b, "RunWith", runner)
 }
 
+// WithOptions sets the QueryOptions sent with the query when it is executed,
+// replacing any set before. Runners that do not implement
+// QueryExecutorOptions ignore them.
+func (b DeleteBuilder) WithOptions(opts QueryOptions) DeleteBuilder {
+	return Set[DeleteBuilder, QueryOptions](b, "QueryOptions", opts)
+}
+
 // Execute builds and executes the query.
 func (b DeleteBuilder) Execute() (QueryResult, error) {
 	data := GetStruct(b).(deleteData)
 	if data.RunWith == nil {
 		return nil, RunnerNotSet
 	}
-	return ExecuteWith(data.RunWith, b)
+	return ExecuteOptionsWith(data.RunWith, data.QueryOptions, b)
 }
 
 // ToN1ql builds the query into a N1QL string and bound args.
@@ -116,6 +213,13 @@ func (b DeleteBuilder) ToN1ql() (string, []any, error) {
 	return data.ToN1ql()
 }
 
+// ToN1qlNamed builds the query into a N1QL string using named parameters
+// ($p1, $p2, ...) and returns the bound args keyed by parameter name
+// (p1, p2, ...).
+func (b DeleteBuilder) ToN1qlNamed() (string, map[string]any, error) {
+	return toN1qlNamed(b.PlaceholderFormat(Named))
+}
+
 // MustN1ql builds the query into a N1QL string and bound args.
 //
 // MustN1ql panics if there are any errors.
@@ -127,6 +231,31 @@ func (b DeleteBuilder) MustN1ql() (string, []any) {
 	return sql, args
 }
 
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(DeleteBuilder) DeleteBuilder.
+func (b DeleteBuilder) Apply(fns ...func(DeleteBuilder) DeleteBuilder) DeleteBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b DeleteBuilder) ApplyIf(cond bool, fn func(DeleteBuilder) DeleteBuilder) DeleteBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Args returns the args the query binds, without finalizing its N1QL.
+func (b DeleteBuilder) Args() ([]any, error) {
+	data := GetStruct(b).(deleteData)
+	_, args, err := data.toN1qlRaw()
+	return append(args, data.ExtraArgs...), err
+}
+
 // Prefix adds an expression to the beginning of the query
 func (b DeleteBuilder) Prefix(sql string, args ...any) DeleteBuilder {
 	return b.PrefixExpr(Expr(sql, args...))
@@ -142,14 +271,47 @@ func (b DeleteBuilder) From(from string) DeleteBuilder {
 	return Set[DeleteBuilder, string](b, "From", from)
 }
 
-// UseKeys sets the USE KEYS clause of the query.
-func (b DeleteBuilder) UseKeys(keys string) DeleteBuilder {
-	return Set[DeleteBuilder, string](b, "UseKeys", keys)
+// UseKeys sets the USE KEYS clause of the query. keys may contain
+// placeholders bound to args, e.g. .UseKeys("?", []string{"k1", "k2"}).
+func (b DeleteBuilder) UseKeys(keys string, args ...any) DeleteBuilder {
+	return Set[DeleteBuilder, N1qlizer](b, "UseKeys", Expr(keys, args...))
+}
+
+// ByKey restricts the query to the document with the given key, bound as an
+// arg: "USE KEYS ?".
+func (b DeleteBuilder) ByKey(key string) DeleteBuilder {
+	return b.UseKeys("?", key)
 }
 
 // Where adds an expression to the WHERE clause of the query.
+// A nil pred adds nothing, and a bool pred adds the constant condition 1=1
+// (true) or 1=0 (false).
 func (b DeleteBuilder) Where(pred any, args ...any) DeleteBuilder {
-	return Append[DeleteBuilder, N1qlizer](b, "WhereParts", Expr(pred, args...))
+	if pred == nil {
+		return b
+	}
+	return Append[DeleteBuilder, N1qlizer](b, "WhereParts", predicate(pred, args...))
+}
+
+// WhereStruct adds an equality condition to the WHERE clause of the query
+// for each non-zero field of the struct v. See SelectBuilder.WhereStruct.
+func (b DeleteBuilder) WhereStruct(v any) DeleteBuilder {
+	return b.Where(structEq{v: v})
+}
+
+// WhereIn adds a "column IN (...)" expression to the WHERE clause of the
+// query. valuesOrSubquery may be a SelectBuilder, a slice of values or a
+// single value; see In.
+func (b DeleteBuilder) WhereIn(column string, valuesOrSubquery any) DeleteBuilder {
+	return b.Where(In(column, valuesOrSubquery))
+}
+
+// WhereCas adds a "META().cas = ?" condition to the WHERE clause of the
+// query, so that it only affects the document if it was not modified since
+// cas was read (optimistic locking). It is combined with the other WHERE
+// expressions using AND.
+func (b DeleteBuilder) WhereCas(cas any) DeleteBuilder {
+	return b.Where("META().cas = ?", cas)
 }
 
 // Limit sets a LIMIT clause on the query.
@@ -171,3 +333,12 @@ func (b DeleteBuilder) Suffix(sql string, args ...any) DeleteBuilder {
 func (b DeleteBuilder) SuffixExpr(expr N1qlizer) DeleteBuilder {
 	return Append[DeleteBuilder, N1qlizer](b, "Suffixes", expr)
 }
+
+// strictParts returns the parts of the query checked by StrictMode.
+func (d *deleteData) strictParts() []N1qlizer {
+	var parts []N1qlizer
+	for _, p := range [][]N1qlizer{d.Prefixes, {d.UseKeys}, d.WhereParts, d.Suffixes} {
+		parts = append(parts, p...)
+	}
+	return parts
+}
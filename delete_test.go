@@ -111,3 +111,49 @@ func TestDeleteWithExpressions(t *testing.T) {
 		t.Errorf("Wrong args: %+v", args)
 	}
 }
+
+// TestDeleteWhereCas tests optimistic locking on the document CAS
+func TestDeleteWhereCas(t *testing.T) {
+	sql, args, err := Delete("users").UseKeys("'user1'").WhereCas(uint64(42)).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "DELETE FROM users USE KEYS 'user1' WHERE META().cas = ?"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != uint64(42) {
+		t.Errorf("Wrong args: %+v", args)
+	}
+}
+
+func TestDeleteByKey(t *testing.T) {
+	sql, args, err := Delete("users").ByKey("user::1").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "DELETE FROM users USE KEYS ?"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"user::1"}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+func TestDeleteWhereBool(t *testing.T) {
+	sql, _, err := Delete("sessions").Where(false).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "DELETE FROM sessions WHERE 1=0"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+}
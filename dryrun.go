@@ -0,0 +1,84 @@
+package n1qlizer
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// DryRunRunner is a QueryRunnerContext that records queries instead of
+// executing them. It is useful for auditing generated queries without a live
+// cluster:
+//
+//	runner := n1qlizer.NewDryRunRunner()
+//	n1qlizer.Select("*").From("users").RunWith(runner).Execute()
+//	fmt.Println(runner.LastQuery())
+//
+// If Logger is set, every query and its args are also written to it.
+type DryRunRunner struct {
+	Logger *log.Logger
+
+	mu        sync.Mutex
+	lastQuery string
+	lastArgs  []any
+	count     int
+}
+
+// NewDryRunRunner returns a new DryRunRunner.
+func NewDryRunRunner() *DryRunRunner {
+	return &DryRunRunner{}
+}
+
+// Execute records the query and args and returns an empty result.
+func (r *DryRunRunner) Execute(query string, args ...any) (QueryResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastQuery = query
+	r.lastArgs = args
+	r.count++
+
+	if r.Logger != nil {
+		r.Logger.Printf("n1qlizer dry run: %s %v", query, args)
+	}
+
+	return emptyResult{}, nil
+}
+
+// ExecuteContext records the query and args and returns an empty result.
+func (r *DryRunRunner) ExecuteContext(ctx context.Context, query string, args ...any) (QueryResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.Execute(query, args...)
+}
+
+// LastQuery returns the last query that would have been executed.
+func (r *DryRunRunner) LastQuery() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastQuery
+}
+
+// LastArgs returns the args of the last query that would have been executed.
+func (r *DryRunRunner) LastArgs() []any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastArgs
+}
+
+// Count returns the number of queries that would have been executed.
+func (r *DryRunRunner) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.count
+}
+
+// emptyResult is a QueryResult without any rows.
+type emptyResult struct{}
+
+func (emptyResult) One(valuePtr any) error { return nil }
+
+func (emptyResult) All(slicePtr any) error { return nil }
+
+func (emptyResult) Close() error { return nil }
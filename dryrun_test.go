@@ -0,0 +1,63 @@
+package n1qlizer
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestDryRunRunner(t *testing.T) {
+	t.Run("Records query and args", func(t *testing.T) {
+		runner := NewDryRunRunner()
+		result, err := Select("*").From("users").Where("id = ?", 1).RunWith(runner).Execute()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if result == nil {
+			t.Fatal("Expected a result, got nil")
+		}
+
+		if runner.LastQuery() != "SELECT * FROM users WHERE id = ?" {
+			t.Errorf("Wrong query: %s", runner.LastQuery())
+		}
+
+		args := runner.LastArgs()
+		if len(args) != 1 || args[0] != 1 {
+			t.Errorf("Wrong args: %+v", args)
+		}
+
+		if runner.Count() != 1 {
+			t.Errorf("Expected 1 execution, got %d", runner.Count())
+		}
+	})
+
+	t.Run("Logs query", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		runner := NewDryRunRunner()
+		runner.Logger = log.New(buf, "", 0)
+
+		_, err := Delete("users").Where("id = ?", 7).RunWith(runner).Execute()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if !strings.Contains(buf.String(), "DELETE FROM users WHERE id = ? [7]") {
+			t.Errorf("Wrong log output: %s", buf.String())
+		}
+	})
+
+	t.Run("With context", func(t *testing.T) {
+		runner := NewDryRunRunner()
+		_, err := Select("*").From("users").RunWithContext(runner).ExecuteContext(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if runner.LastQuery() != "SELECT * FROM users" {
+			t.Errorf("Wrong query: %s", runner.LastQuery())
+		}
+	})
+}
@@ -3,7 +3,9 @@ package n1qlizer
 import (
 	"fmt"
 	"io"
+	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -14,6 +16,12 @@ type expr struct {
 
 // Expr builds an expression from a SQL fragment and arguments.
 // The first argument should be a string, which may contain ? placeholders.
+//
+// A slice or array arg bound to a placeholder right after the IN keyword is
+// expanded into one placeholder per element, so Expr("id IN ?", []int{1, 2})
+// renders "id IN (?,?)" with args 1 and 2. As with Eq, "id IN ?" with an
+// empty slice renders the constant condition 1=0 and "id NOT IN ?" 1=1.
+// Slices bound anywhere else, e.g. "USE KEYS ?", are bound as a whole.
 func Expr(sql any, args ...any) N1qlizer {
 	sqlStr, ok := sql.(string)
 	if !ok {
@@ -35,16 +43,17 @@ func (e expr) ToN1ql() (string, []any, error) {
 		return "", nil, fmt.Errorf("expr: not enough arguments for placeholders")
 	}
 
-	// Check if the expr arguments contain N1qlizer instances
+	// Check if the expr arguments contain N1qlizer instances or slices that
+	// may need expanding
 	simple := true
 	for _, arg := range e.args {
-		if _, ok := arg.(N1qlizer); ok {
+		if _, ok := arg.(N1qlizer); ok || isExpandable(arg) {
 			simple = false
 			break
 		}
 	}
 
-	// If no N1qlizer arguments, just return the SQL and args as-is
+	// If no such arguments, just return the SQL and args as-is
 	if simple {
 		return e.sql, e.args, nil
 	}
@@ -75,6 +84,30 @@ func (e expr) ToN1ql() (string, []any, error) {
 
 			buf.WriteString(nestedSQL)
 			newArgs = append(newArgs, nestedArgs...)
+		} else if isExpandable(arg) && followsIn(buf.String()) {
+			rv := reflect.ValueOf(arg)
+			if rv.Len() == 0 {
+				rest, operand, not, ok := splitInOperand(buf.String())
+				if !ok {
+					buf.WriteString("[]")
+					continue
+				}
+				newArgs = newArgs[:len(newArgs)-strings.Count(operand, "?")]
+				buf.Reset()
+				buf.WriteString(rest)
+				if not {
+					buf.WriteString("1=1")
+				} else {
+					buf.WriteString("1=0")
+				}
+				continue
+			}
+			placeholders := make([]string, rv.Len())
+			for j := range placeholders {
+				placeholders[j] = "?"
+				newArgs = append(newArgs, rv.Index(j).Interface())
+			}
+			buf.WriteString("(" + strings.Join(placeholders, ",") + ")")
 		} else {
 			buf.WriteString("?")
 			newArgs = append(newArgs, arg)
@@ -84,6 +117,132 @@ func (e expr) ToN1ql() (string, []any, error) {
 	return buf.String(), newArgs, nil
 }
 
+// isExpandable reports whether arg is a slice or array that Expr expands into
+// one placeholder per element when it is bound right after the IN keyword.
+// Byte slices are bound as a whole.
+func isExpandable(arg any) bool {
+	rv := reflect.ValueOf(arg)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return false
+	}
+	return rv.Type().Elem().Kind() != reflect.Uint8
+}
+
+// followsIn reports whether sql ends with the IN keyword, ignoring trailing
+// whitespace.
+func followsIn(sql string) bool {
+	sql = strings.TrimRight(sql, " \t\n")
+	if len(sql) < 2 || !strings.EqualFold(sql[len(sql)-2:], "IN") {
+		return false
+	}
+	if len(sql) == 2 {
+		return true
+	}
+	c := sql[len(sql)-3]
+	return !(c == '_' || c == '`' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z')
+}
+
+// checkStrict returns an error if the N1QL of an expression built from a
+// string with Expr looks like it has user input concatenated into it: a
+// statement separator, a comment marker or an unterminated quote outside of
+// quoted literals. Other N1qlizers are built by n1qlizer and are not checked.
+func checkStrict(parts ...N1qlizer) error {
+	for _, part := range parts {
+		e, ok := part.(expr)
+		if !ok {
+			continue
+		}
+		if problem := suspiciousN1ql(e.sql); problem != "" {
+			return fmt.Errorf("strict mode: expression %q contains %s", e.sql, problem)
+		}
+	}
+	return nil
+}
+
+// suspiciousN1ql describes the first suspicious pattern found in sql, or
+// returns an empty string.
+func suspiciousN1ql(sql string) string {
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; c {
+		case '\'', '"', '`':
+			end := closingQuote(sql, i)
+			if end < 0 {
+				return "an unterminated quote"
+			}
+			i = end
+		case ';':
+			return "a statement separator"
+		case '-', '/':
+			if i+1 < len(sql) && (c == '-' && sql[i+1] == '-' || c == '/' && sql[i+1] == '*') {
+				return "a comment marker"
+			}
+		}
+	}
+	return ""
+}
+
+// splitInOperand splits sql, which ends with the IN keyword, into the text
+// before the left operand of IN, the operand itself, and whether the keyword
+// is NOT IN. ok is false if no operand is found.
+func splitInOperand(sql string) (rest, operand string, not, ok bool) {
+	sql = strings.TrimRight(sql, " \t\n")
+	sql = strings.TrimRight(sql[:len(sql)-2], " \t\n")
+	if n := len(sql); n >= 3 && strings.EqualFold(sql[n-3:], "NOT") && (n == 3 || !isIdentByte(sql[n-4])) {
+		not = true
+		sql = strings.TrimRight(sql[:n-3], " \t\n")
+	}
+
+	// The operand starts after the last separator outside of quotes, at the
+	// nesting level of the end of sql.
+	start := 0
+	var starts []int
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; c {
+		case '\'', '"', '`':
+			end := closingQuote(sql, i)
+			if end < 0 {
+				return "", "", false, false
+			}
+			i = end
+		case '(', '[':
+			starts = append(starts, start)
+			start = i + 1
+		case ')', ']':
+			if len(starts) == 0 {
+				return "", "", false, false
+			}
+			start = starts[len(starts)-1]
+			starts = starts[:len(starts)-1]
+		case ' ', '\t', '\n', ',':
+			start = i + 1
+		}
+	}
+
+	if start == len(sql) {
+		return "", "", false, false
+	}
+	return sql[:start], sql[start:], not, true
+}
+
+// closingQuote returns the index of the quote closing the one at start, or -1.
+// Quotes are escaped with a backslash or by doubling them.
+func closingQuote(sql string, start int) int {
+	q := sql[start]
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			i++
+		case q:
+			if i+1 < len(sql) && sql[i+1] == q {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
 // newPart creates a new Sqlizer from a simple string
 func newPart(sql string) N1qlizer {
 	return expr{sql: sql}
@@ -101,7 +260,7 @@ func Alias(expr N1qlizer, alias string) N1qlizer {
 }
 
 func (e aliasExpr) ToN1ql() (string, []any, error) {
-	sql, args, err := e.expr.ToN1ql()
+	sql, args, err := nestedToN1ql(e.expr)
 	if err != nil {
 		return "", nil, err
 	}
@@ -227,6 +386,18 @@ func comparisonExpr(m map[string]any, op string) (sql string, args []any, err er
 	return
 }
 
+// predicate builds a WHERE expression from pred and args like Expr, except
+// that a bool pred renders the constant condition 1=1 or 1=0.
+func predicate(pred any, args ...any) N1qlizer {
+	if b, ok := pred.(bool); ok {
+		if b {
+			return newPart("1=1")
+		}
+		return newPart("1=0")
+	}
+	return Expr(pred, args...)
+}
+
 // equalityToN1ql generates SQL and args for an equality condition.
 func equalityToN1ql(key string, val any) (sql string, args []any, err error) {
 	switch v := val.(type) {
@@ -340,6 +511,304 @@ func andOrToN1ql(ex []N1qlizer, sep string) (sql string, args []any, err error)
 	return fmt.Sprintf("(%s)", strings.Join(parts, fmt.Sprintf(" %s ", sep))), args, nil
 }
 
+// inExpr tests membership of a column in a list of values or a subquery.
+type inExpr struct {
+	column string
+	values any
+}
+
+// In builds a "column IN (...)" expression. valuesOrSubquery may be:
+//
+//   - a SelectBuilder, rendered as a subquery: "column IN (SELECT ...)"
+//   - a slice, expanded to one placeholder per element: "column IN (?,?)"
+//   - any other N1qlizer, such as Literals, spliced as is: "column IN [...]"
+//   - a single value, treated as a one-element list: "column IN (?)"
+//
+// As with Expr, a byte slice is a single value. An empty slice yields a
+// condition that is always false.
+func In(column string, valuesOrSubquery any) N1qlizer {
+	return inExpr{column: column, values: valuesOrSubquery}
+}
+
+func (e inExpr) ToN1ql() (string, []any, error) {
+	switch v := e.values.(type) {
+	case SelectBuilder:
+		sql, args, err := subquery{query: v}.ToN1ql()
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("%s IN %s", e.column, sql), args, nil
+	case N1qlizer:
+		sql, args, err := nestedToN1ql(v)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("%s IN %s", e.column, sql), args, nil
+	}
+
+	values := []any{e.values}
+	if isExpandable(e.values) {
+		rv := reflect.ValueOf(e.values)
+		values = make([]any, rv.Len())
+		for i := range values {
+			values[i] = rv.Index(i).Interface()
+		}
+	}
+
+	return equalityToN1ql(e.column, values)
+}
+
+// notExpr negates an expression with the "NOT" operator.
+type notExpr struct {
+	expr N1qlizer
+}
+
+// Not negates any expression, e.g. Not(And{Eq{"a": 1}, Gt{"b": 2}}) renders
+// "NOT ((a = ? AND b > ?))". Negating an empty expression yields an empty
+// expression.
+func Not(expr N1qlizer) N1qlizer {
+	return notExpr{expr: expr}
+}
+
+func (n notExpr) ToN1ql() (string, []any, error) {
+	sql, args, err := n.expr.ToN1ql()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if sql == "" {
+		return "", nil, nil
+	}
+
+	return fmt.Sprintf("NOT (%s)", sql), args, nil
+}
+
+// EqOrMissing matches documents where field equals value or is missing,
+// rendering "(field = ? OR field IS MISSING)".
+func EqOrMissing(field string, value any) N1qlizer {
+	return Expr(fmt.Sprintf("(%s = ? OR %s IS MISSING)", field, field), value)
+}
+
+// Coalesced returns field, or fallback if field is missing or null,
+// rendering "IFMISSINGORNULL(field, ?)".
+func Coalesced(field string, fallback any) N1qlizer {
+	return Expr(fmt.Sprintf("IFMISSINGORNULL(%s, ?)", field), fallback)
+}
+
+// existsExpr tests whether a subquery returns any rows.
+type existsExpr struct {
+	query N1qlizer
+	not   bool
+}
+
+// Exists builds an "EXISTS (subquery)" expression. The subquery may reference
+// aliases of the outer query (a correlated subquery), for example:
+//
+//	Select("u.name").From("users u").
+//		Where(Exists(Select("1").From("orders o").Where("o.userId = META(u).id")))
+func Exists(query N1qlizer) N1qlizer {
+	return existsExpr{query: query}
+}
+
+// NotExists builds a "NOT EXISTS (subquery)" expression.
+func NotExists(query N1qlizer) N1qlizer {
+	return existsExpr{query: query, not: true}
+}
+
+func (e existsExpr) ToN1ql() (string, []any, error) {
+	sql, args, err := subquery{query: e.query}.ToN1ql()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if e.not {
+		return "NOT EXISTS " + sql, args, nil
+	}
+	return "EXISTS " + sql, args, nil
+}
+
+// IsArray renders "ISARRAY(expr)", true if expr is an array.
+func IsArray(expr string) N1qlizer {
+	return typeFunc("ISARRAY", expr)
+}
+
+// IsObject renders "ISOBJECT(expr)", true if expr is an object.
+func IsObject(expr string) N1qlizer {
+	return typeFunc("ISOBJECT", expr)
+}
+
+// IsString renders "ISSTRING(expr)", true if expr is a string.
+func IsString(expr string) N1qlizer {
+	return typeFunc("ISSTRING", expr)
+}
+
+// IsNumber renders "ISNUMBER(expr)", true if expr is a number.
+func IsNumber(expr string) N1qlizer {
+	return typeFunc("ISNUMBER", expr)
+}
+
+// IsBoolean renders "ISBOOLEAN(expr)", true if expr is a boolean.
+func IsBoolean(expr string) N1qlizer {
+	return typeFunc("ISBOOLEAN", expr)
+}
+
+// TypeOf renders "TYPE(expr)", the name of the type of expr, e.g. "string".
+func TypeOf(expr string) N1qlizer {
+	return typeFunc("TYPE", expr)
+}
+
+// ArrayAgg returns an ARRAY_AGG aggregate call, collecting the values of expr
+// in each group into an array.
+func ArrayAgg(expr string) N1qlizer {
+	return newPart(fmt.Sprintf("ARRAY_AGG(%s)", expr))
+}
+
+// ArrayAggDistinct returns an ARRAY_AGG(DISTINCT ...) aggregate call,
+// collecting the distinct values of expr in each group into an array.
+func ArrayAggDistinct(expr string) N1qlizer {
+	return newPart(fmt.Sprintf("ARRAY_AGG(DISTINCT %s)", expr))
+}
+
+// CountDistinct returns a COUNT(DISTINCT expr) aggregate call.
+func CountDistinct(expr string) N1qlizer {
+	return newPart(fmt.Sprintf("COUNT(DISTINCT %s)", expr))
+}
+
+// SumDistinct returns a SUM(DISTINCT expr) aggregate call.
+func SumDistinct(expr string) N1qlizer {
+	return newPart(fmt.Sprintf("SUM(DISTINCT %s)", expr))
+}
+
+// AvgDistinct returns an AVG(DISTINCT expr) aggregate call.
+func AvgDistinct(expr string) N1qlizer {
+	return newPart(fmt.Sprintf("AVG(DISTINCT %s)", expr))
+}
+
+// Contains renders "CONTAINS(field, ?)" with substr bound, true if the string
+// field contains substr.
+func Contains(field string, substr any) N1qlizer {
+	return Expr(fmt.Sprintf("CONTAINS(%s, ?)", field), substr)
+}
+
+// Position renders "POSITION(field, ?)" with substr bound, the zero-based
+// position of substr in the string field, or -1 if it is not found.
+func Position(field string, substr any) N1qlizer {
+	return Expr(fmt.Sprintf("POSITION(%s, ?)", field), substr)
+}
+
+// Substr renders "SUBSTR(field, ?, ?)" with pos and length bound, the
+// substring of field starting at the zero-based pos. A nil length renders
+// "SUBSTR(field, ?)", the rest of the string.
+func Substr(field string, pos, length any) N1qlizer {
+	if length == nil {
+		return Expr(fmt.Sprintf("SUBSTR(%s, ?)", field), pos)
+	}
+	return Expr(fmt.Sprintf("SUBSTR(%s, ?, ?)", field), pos, length)
+}
+
+// Lower renders "LOWER(field)".
+func Lower(field string) N1qlizer {
+	return typeFunc("LOWER", field)
+}
+
+// Upper renders "UPPER(field)".
+func Upper(field string) N1qlizer {
+	return typeFunc("UPPER", field)
+}
+
+// Length renders "LENGTH(field)", the length of the string field.
+func Length(field string) N1qlizer {
+	return typeFunc("LENGTH", field)
+}
+
+// SearchFields builds an OR of "field LIKE ?" over fields, binding pattern
+// once per field, for simple search across several columns:
+//
+//	SearchFields("%john%", "name", "email")
+//
+// renders "(name LIKE ? OR email LIKE ?)". Without fields it renders nothing.
+func SearchFields(pattern any, fields ...string) N1qlizer {
+	or := make(Or, len(fields))
+	for i, field := range fields {
+		or[i] = Expr(fmt.Sprintf("%s LIKE ?", field), pattern)
+	}
+	return or
+}
+
+// SearchFieldsFold is the case-insensitive variant of SearchFields, rendering
+// "(LOWER(name) LIKE LOWER(?) OR LOWER(email) LIKE LOWER(?))".
+func SearchFieldsFold(pattern any, fields ...string) N1qlizer {
+	or := make(Or, len(fields))
+	for i, field := range fields {
+		or[i] = Expr(fmt.Sprintf("LOWER(%s) LIKE LOWER(?)", field), pattern)
+	}
+	return or
+}
+
+func typeFunc(name, expr string) N1qlizer {
+	return newPart(fmt.Sprintf("%s(%s)", name, expr))
+}
+
+// Literals renders the given strings as an array of quoted N1QL string
+// literals rather than placeholders, e.g. Literals("a", "b") renders
+// `["a", "b"]`. It is meant for static filter sets, where the query planner
+// can make use of the literal values:
+//
+//	Select("*").From("users").Where(Expr("status IN ?", Literals("active", "pending")))
+func Literals(values ...string) N1qlizer {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quoteString(v)
+	}
+	return newPart("[" + strings.Join(quoted, ", ") + "]")
+}
+
+// number is the set of types accepted by NumberLiterals.
+type number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// NumberLiterals renders the given numbers as an array of N1QL number
+// literals, e.g. NumberLiterals(1, 2, 3) renders "[1, 2, 3]".
+func NumberLiterals[T number](values ...T) N1qlizer {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = fmt.Sprintf("%v", v)
+	}
+	return newPart("[" + strings.Join(formatted, ", ") + "]")
+}
+
+// Bool renders b as the bare N1QL keyword true or false rather than a
+// placeholder, for contexts that require a literal such as object literals:
+//
+//	Inline(map[string]any{"explain": Bool(true)})
+func Bool(b bool) N1qlizer {
+	return newPart(strconv.FormatBool(b))
+}
+
+// Null renders the N1QL NULL literal.
+func Null() N1qlizer {
+	return newPart("NULL")
+}
+
+// Missing renders the N1QL MISSING literal, e.g. as the ELSE branch of a
+// CASE expression to omit a field from the result.
+func Missing() N1qlizer {
+	return newPart("MISSING")
+}
+
+// quoteString renders s as a double-quoted N1QL string literal. Question
+// marks are written as a unicode escape so that they are not mistaken for
+// placeholders.
+func quoteString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "?", `\u003f`)
+	return `"` + s + `"`
+}
+
 // writePlaceholders generates placeholder syntax for the given count, separated by commas.
 func writePlaceholders(w io.Writer, count int) error {
 	for i := 0; i < count; i++ {
@@ -356,3 +825,59 @@ func writePlaceholders(w io.Writer, count int) error {
 	}
 	return nil
 }
+
+// structEq is an equality expression built from the fields of a struct.
+type structEq struct {
+	v any
+}
+
+// ToN1ql renders the fields of the struct selected as described on
+// SelectBuilder.WhereStruct as an Eq.
+func (s structEq) ToN1ql() (string, []any, error) {
+	rv := reflect.ValueOf(s.v)
+	if !rv.IsValid() {
+		return "", nil, nil
+	}
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("where struct: expected a struct, got %T", s.v)
+	}
+
+	eq := Eq{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		includeZero := field.Tag.Get("n1qlizer") == "includezero"
+
+		value := rv.Field(i)
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				if includeZero {
+					eq[name] = nil
+				}
+				continue
+			}
+			value = value.Elem()
+		} else if value.IsZero() && !includeZero {
+			continue
+		}
+		eq[name] = value.Interface()
+	}
+	return eq.ToN1ql()
+}
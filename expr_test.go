@@ -489,3 +489,457 @@ func TestAndOr(t *testing.T) {
 		}
 	})
 }
+
+func TestNot(t *testing.T) {
+	t.Run("Not And", func(t *testing.T) {
+		expr := Not(And{Eq{"status": "active"}, Gt{"age": 30}})
+		sql, args, err := expr.ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build Not expression: %v", err)
+		}
+
+		if sql != "NOT ((status = ? AND age > ?))" {
+			t.Errorf("Expected 'NOT ((status = ? AND age > ?))', got '%s'", sql)
+		}
+
+		if len(args) != 2 || args[0] != "active" || args[1] != 30 {
+			t.Errorf("Expected args [active 30], got %v", args)
+		}
+	})
+
+	t.Run("Not in WHERE", func(t *testing.T) {
+		sql, args, err := Select("*").From("users").Where(Not(Expr("name LIKE ?", "a%"))).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		if sql != "SELECT * FROM users WHERE NOT (name LIKE ?)" {
+			t.Errorf("Expected 'SELECT * FROM users WHERE NOT (name LIKE ?)', got '%s'", sql)
+		}
+
+		if len(args) != 1 || args[0] != "a%" {
+			t.Errorf("Expected args [a%%], got %v", args)
+		}
+	})
+
+	t.Run("Not empty", func(t *testing.T) {
+		sql, args, err := Not(And{}).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build Not expression: %v", err)
+		}
+
+		if sql != "" {
+			t.Errorf("Expected empty string, got '%s'", sql)
+		}
+
+		if len(args) != 0 {
+			t.Errorf("Expected empty args, got %v", args)
+		}
+	})
+}
+
+func TestMissingHelpers(t *testing.T) {
+	t.Run("EqOrMissing", func(t *testing.T) {
+		sql, args, err := EqOrMissing("u.status", "active").ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build expression: %v", err)
+		}
+
+		if sql != "(u.status = ? OR u.status IS MISSING)" {
+			t.Errorf("Expected '(u.status = ? OR u.status IS MISSING)', got '%s'", sql)
+		}
+
+		if len(args) != 1 || args[0] != "active" {
+			t.Errorf("Expected args [active], got %v", args)
+		}
+	})
+
+	t.Run("Coalesced", func(t *testing.T) {
+		sql, args, err := Coalesced("u.score", 0).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build expression: %v", err)
+		}
+
+		if sql != "IFMISSINGORNULL(u.score, ?)" {
+			t.Errorf("Expected 'IFMISSINGORNULL(u.score, ?)', got '%s'", sql)
+		}
+
+		if len(args) != 1 || args[0] != 0 {
+			t.Errorf("Expected args [0], got %v", args)
+		}
+	})
+
+	t.Run("Coalesced in comparison", func(t *testing.T) {
+		sql, args, err := Select("*").From("users u").Where(Expr("? > ?", Coalesced("u.score", 0), 10)).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		if sql != "SELECT * FROM users u WHERE IFMISSINGORNULL(u.score, ?) > ?" {
+			t.Errorf("Wrong SQL: %s", sql)
+		}
+
+		if len(args) != 2 || args[0] != 0 || args[1] != 10 {
+			t.Errorf("Expected args [0 10], got %v", args)
+		}
+	})
+}
+
+func TestTypeFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     N1qlizer
+		expected string
+	}{
+		{"IsArray", IsArray("u.tags"), "ISARRAY(u.tags)"},
+		{"IsObject", IsObject("u.address"), "ISOBJECT(u.address)"},
+		{"IsString", IsString("u.name"), "ISSTRING(u.name)"},
+		{"IsNumber", IsNumber("u.age"), "ISNUMBER(u.age)"},
+		{"IsBoolean", IsBoolean("u.active"), "ISBOOLEAN(u.active)"},
+		{"TypeOf", TypeOf("u.age"), "TYPE(u.age)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, sql)
+			}
+
+			if len(args) != 0 {
+				t.Errorf("Expected empty args, got %v", args)
+			}
+		})
+	}
+
+	sql, args, err := Select("*").From("users u").
+		Where(IsArray("u.tags")).
+		Where(Expr("? = ?", TypeOf("u.age"), "number")).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "SELECT * FROM users u WHERE ISARRAY(u.tags) AND TYPE(u.age) = ?" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	if len(args) != 1 || args[0] != "number" {
+		t.Errorf("Expected args [number], got %v", args)
+	}
+}
+
+func TestLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     N1qlizer
+		expected string
+	}{
+		{"Strings", Literals("a", "b", "c"), `["a", "b", "c"]`},
+		{"Escaped strings", Literals(`say "hi"`, `C:\tmp`, "why?"), `["say \"hi\"", "C:\\tmp", "why\u003f"]`},
+		{"Empty", Literals(), `[]`},
+		{"Integers", NumberLiterals(1, 2, 3), `[1, 2, 3]`},
+		{"Floats", NumberLiterals(1.5, 2.25), `[1.5, 2.25]`},
+		{"True", Bool(true), `true`},
+		{"False", Bool(false), `false`},
+		{"Null", Null(), `NULL`},
+		{"Missing", Missing(), `MISSING`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, sql)
+			}
+
+			if len(args) != 0 {
+				t.Errorf("Expected empty args, got %v", args)
+			}
+		})
+	}
+
+	sql, args, err := Select("*").From("users").
+		Where(Expr("status IN ?", Literals("active", "pending?"))).
+		Where("age > ?", 18).
+		PlaceholderFormat(Dollar).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := `SELECT * FROM users WHERE status IN ["active", "pending\u003f"] AND age > $1`
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != 18 {
+		t.Errorf("Expected args [18], got %v", args)
+	}
+}
+
+func TestArrayAgg(t *testing.T) {
+	sql, args, err := Select("u.country").
+		ColumnAs(ArrayAgg("u.name"), "names").
+		ColumnAs(ArrayAggDistinct("u.city"), "cities").
+		From("users u").
+		Where("u.active = ?", true).
+		GroupBy("u.country").
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT u.country, (ARRAY_AGG(u.name)) AS names, (ARRAY_AGG(DISTINCT u.city)) AS cities FROM users u WHERE u.active = ? GROUP BY u.country"
+	if sql != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != true {
+		t.Errorf("Expected args [true], got %v", args)
+	}
+}
+
+func TestDistinctAggregates(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     N1qlizer
+		expected string
+	}{
+		{"CountDistinct", CountDistinct("o.userId"), "COUNT(DISTINCT o.userId)"},
+		{"SumDistinct", SumDistinct("o.total"), "SUM(DISTINCT o.total)"},
+		{"AvgDistinct", AvgDistinct("o.total"), "AVG(DISTINCT o.total)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, sql)
+			}
+
+			if len(args) != 0 {
+				t.Errorf("Expected empty args, got %v", args)
+			}
+		})
+	}
+
+	sql, _, err := Select("o.day").ColumnAs(CountDistinct("o.userId"), "buyers").From("orders o").GroupBy("o.day").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "SELECT o.day, (COUNT(DISTINCT o.userId)) AS buyers FROM orders o GROUP BY o.day" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+}
+
+func TestBoolNullMissingInExpressions(t *testing.T) {
+	sql, args, err := Select("*").From("users").
+		Where(Expr("u.active = ?", Bool(true))).
+		Where(Expr("u.deleted IS NOT ?", Null())).
+		Where(Expr("u.meta = ?", Inline(map[string]interface{}{"verified": Bool(false), "note": Missing()}))).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := `SELECT * FROM users WHERE u.active = true AND u.deleted IS NOT NULL AND u.meta = {"note": MISSING, "verified": false}`
+	if sql != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, sql)
+	}
+
+	if len(args) != 0 {
+		t.Errorf("Expected empty args, got %v", args)
+	}
+}
+
+func TestStringFunctions(t *testing.T) {
+	tests := []struct {
+		name         string
+		expr         N1qlizer
+		expected     string
+		expectedArgs []interface{}
+	}{
+		{"Contains", Contains("u.name", "oh"), "CONTAINS(u.name, ?)", []interface{}{"oh"}},
+		{"Position", Position("u.email", "@"), "POSITION(u.email, ?)", []interface{}{"@"}},
+		{"Substr", Substr("u.code", 0, 3), "SUBSTR(u.code, ?, ?)", []interface{}{0, 3}},
+		{"Substr to end", Substr("u.code", 4, nil), "SUBSTR(u.code, ?)", []interface{}{4}},
+		{"Substr expression", Substr("u.code", Position("u.code", "-"), nil), "SUBSTR(u.code, POSITION(u.code, ?))", []interface{}{"-"}},
+		{"Lower", Lower("u.name"), "LOWER(u.name)", nil},
+		{"Upper", Upper("u.name"), "UPPER(u.name)", nil},
+		{"Length", Length("u.name"), "LENGTH(u.name)", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, sql)
+			}
+
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Expected args %v, got %v", tt.expectedArgs, args)
+			}
+		})
+	}
+
+	sql, args, err := Select("*").From("users u").
+		Where(Contains("LOWER(u.name)", "john")).
+		Where(Expr("? > 0", Position("u.email", "@"))).
+		PlaceholderFormat(Dollar).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users u WHERE CONTAINS(LOWER(u.name), $1) AND POSITION(u.email, $2) > 0"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"john", "@"}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+func TestExprExpandsInSlices(t *testing.T) {
+	tests := []struct {
+		name         string
+		expr         N1qlizer
+		expected     string
+		expectedArgs []interface{}
+	}{
+		{"Int slice", Expr("id IN ?", []int{1, 2, 3}), "id IN (?,?,?)", []interface{}{1, 2, 3}},
+		{"Lowercase keyword", Expr("id in ?", []string{"a"}), "id in (?)", []interface{}{"a"}},
+		{"NOT IN", Expr("id NOT IN ?", [2]int{4, 5}), "id NOT IN (?,?)", []interface{}{4, 5}},
+		{"Mixed args", Expr("a = ? AND b IN ? AND c > ?", "x", []string{"y", "z"}, 10), "a = ? AND b IN (?,?) AND c > ?", []interface{}{"x", "y", "z", 10}},
+		{"Empty slice", Expr("id IN ?", []int{}), "1=0", nil},
+		{"Empty slice NOT IN", Expr("id NOT IN ?", []string{}), "1=1", nil},
+		{"Empty slice among args", Expr("a = ? AND (LOWER(b) IN ? OR c > ?)", "x", []string{}, 10), "a = ? AND (1=0 OR c > ?)", []interface{}{"x", 10}},
+		{"Empty slice with bound operand", Expr("a = ? OR SUBSTR(b, ?) IN ?", "x", 2, []int{}), "a = ? OR 1=0", []interface{}{"x"}},
+		{"Not after IN", Expr("ARRAY_CONTAINS(tags, ?) OR tags = ?", "a", []string{"a", "b"}), "ARRAY_CONTAINS(tags, ?) OR tags = ?", []interface{}{"a", []string{"a", "b"}}},
+		{"Keyword suffix", Expr("JOIN ?", []int{1}), "JOIN ?", []interface{}{[]int{1}}},
+		{"Byte slice", Expr("data IN ?", []byte("ab")), "data IN ?", []interface{}{[]byte("ab")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, sql)
+			}
+
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Expected args %v, got %v", tt.expectedArgs, args)
+			}
+		})
+	}
+
+	sql, args, err := Select("*").From("users").UseKeys("?", []string{"u1", "u2"}).
+		Where("age IN ?", []int{30, 40}).
+		PlaceholderFormat(Dollar).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users USE KEYS $1 WHERE age IN ($2,$3)"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{[]string{"u1", "u2"}, 30, 40}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+
+	// An empty IN list renders the same constant condition as Eq and NotEq
+	for _, pair := range [][2]N1qlizer{
+		{Expr("id IN ?", []any{}), Eq{"id": []any{}}},
+		{Expr("id NOT IN ?", []any{}), NotEq{"id": []any{}}},
+	} {
+		exprSQL, _, err := pair[0].ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build expression: %v", err)
+		}
+		eqSQL, _, err := pair[1].ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build expression: %v", err)
+		}
+		if exprSQL != eqSQL {
+			t.Errorf("Expected Expr to render %q like Eq, got %q", eqSQL, exprSQL)
+		}
+	}
+}
+
+func TestSearchFields(t *testing.T) {
+	tests := []struct {
+		name         string
+		expr         N1qlizer
+		expected     string
+		expectedArgs []interface{}
+	}{
+		{"Several fields", SearchFields("%john%", "name", "email", "phone"), "(name LIKE ? OR email LIKE ? OR phone LIKE ?)", []interface{}{"%john%", "%john%", "%john%"}},
+		{"Single field", SearchFields("jo%", "name"), "name LIKE ?", []interface{}{"jo%"}},
+		{"No fields", SearchFields("jo%"), "", nil},
+		{"Case-insensitive", SearchFieldsFold("%John%", "name", "email"), "(LOWER(name) LIKE LOWER(?) OR LOWER(email) LIKE LOWER(?))", []interface{}{"%John%", "%John%"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, sql)
+			}
+
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Expected args %v, got %v", tt.expectedArgs, args)
+			}
+		})
+	}
+
+	sql, args, err := Select("*").From("users").
+		Where("active = ?", true).
+		Where(SearchFields("%jo%", "name", "email")).
+		PlaceholderFormat(Dollar).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users WHERE active = $1 AND (name LIKE $2 OR email LIKE $3)"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{true, "%jo%", "%jo%"}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
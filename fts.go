@@ -1,8 +1,11 @@
 package n1qlizer
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // FTSSearchOptions represents options for a Full-Text Search query
@@ -17,7 +20,137 @@ type FTSSearchOptions struct {
 	Fields    []string // Fields to search in
 }
 
-// FTSMatch creates a Full-Text Search match expression
+// FTSFacet defines a facet of a search service request. A facet without
+// ranges is a term facet, counting the most frequent terms of Field.
+type FTSFacet struct {
+	Name          string
+	Field         string
+	Size          int
+	NumericRanges []FTSFacetNumericRange
+	DateRanges    []FTSFacetDateRange
+}
+
+// FTSFacetNumericRange is a bucket of a numeric range facet. A nil Min or Max
+// leaves the range open on that side.
+type FTSFacetNumericRange struct {
+	Name string   `json:"name"`
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+}
+
+// FTSFacetDateRange is a bucket of a date range facet, with RFC 3339 Start and
+// End dates. An empty Start or End leaves the range open on that side.
+type FTSFacetDateRange struct {
+	Name  string `json:"name"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// ftsFacetJSON is the JSON representation of a FTSFacet.
+type ftsFacetJSON struct {
+	Field         string                 `json:"field"`
+	Size          int                    `json:"size"`
+	NumericRanges []FTSFacetNumericRange `json:"numeric_ranges,omitempty"`
+	DateRanges    []FTSFacetDateRange    `json:"date_ranges,omitempty"`
+}
+
+// ftsFacets renders facets as the JSON object of the "facets" option, keyed
+// by facet name.
+func ftsFacets(facets []FTSFacet) (string, error) {
+	m := make(map[string]ftsFacetJSON, len(facets))
+	for _, f := range facets {
+		if f.Name == "" || f.Field == "" {
+			return "", fmt.Errorf("FTS facets must have a name and a field")
+		}
+		m[f.Name] = ftsFacetJSON{
+			Field:         f.Field,
+			Size:          f.Size,
+			NumericRanges: f.NumericRanges,
+			DateRanges:    f.DateRanges,
+		}
+	}
+	return ftsJSON(m)
+}
+
+// FTSGeoSort sorts search results by their distance from a location, for use
+// in the "sort" option of FTSSearchService.
+type FTSGeoSort struct {
+	Field      string
+	Lat, Lon   float64
+	Unit       string // e.g. "km" or "mi"
+	Descending bool
+}
+
+// MarshalJSON renders the geo distance sort object.
+func (g FTSGeoSort) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		By       string             `json:"by"`
+		Field    string             `json:"field"`
+		Location map[string]float64 `json:"location"`
+		Unit     string             `json:"unit,omitempty"`
+		Desc     bool               `json:"desc,omitempty"`
+	}{
+		By:       "geo_distance",
+		Field:    g.Field,
+		Location: map[string]float64{"lat": g.Lat, "lon": g.Lon},
+		Unit:     g.Unit,
+		Desc:     g.Descending,
+	})
+}
+
+// ftsSort renders the "sort" option. Sort specs are either strings, naming a
+// field or "_score" or "_id", prefixed with "-" for descending order, or
+// FTSGeoSort values.
+func ftsSort(specs []interface{}) (string, error) {
+	for _, spec := range specs {
+		switch spec.(type) {
+		case string, FTSGeoSort:
+		default:
+			return "", fmt.Errorf("FTS sort specs must be strings or FTSGeoSort, got %T", spec)
+		}
+	}
+	return ftsJSON(specs)
+}
+
+// ftsJSON marshals v for inlining in a SEARCH call. Question marks, which
+// can only occur inside JSON strings, are escaped so that they are not
+// mistaken for placeholders.
+func ftsJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(string(b), "?", `\u003f`), nil
+}
+
+// ftsPhrase quotes s as a phrase of a FTS query string. Inside the quotes
+// only '"' and '\' have a meaning, so only they are escaped.
+func ftsPhrase(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// ftsSpecialChars are the characters with a meaning in FTS query strings.
+const ftsSpecialChars = `+-=&|><!(){}[]^"~*?:\/`
+
+// EscapeFTS escapes the FTS query string special characters in s with a
+// backslash, so that s is matched literally, e.g. EscapeFTS("c++") returns
+// `c\+\+`. FTSMatch escapes field names with it; use EscapeFTS for user
+// input embedded in other query strings.
+func EscapeFTS(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(ftsSpecialChars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// FTSMatch creates a Full-Text Search match expression. query is a FTS query
+// string and may use its syntax, such as wildcards; see EscapeFTS.
 func FTSMatch(query string, options ...FTSSearchOptions) N1qlizer {
 	opts := FTSSearchOptions{}
 	if len(options) > 0 {
@@ -37,11 +170,11 @@ func FTSMatch(query string, options ...FTSSearchOptions) N1qlizer {
 	if len(opts.Fields) > 0 {
 		fieldQueries := make([]string, len(opts.Fields))
 		for i, field := range opts.Fields {
-			fieldQueries[i] = fmt.Sprintf("%s:%s", field, query)
+			fieldQueries[i] = fmt.Sprintf("%s:%s", EscapeFTS(field), query)
 		}
-		searchQuery += fmt.Sprintf("\"%s\"", strings.Join(fieldQueries, " OR "))
+		searchQuery += quoteString(strings.Join(fieldQueries, " OR "))
 	} else {
-		searchQuery += fmt.Sprintf("\"%s\"", query)
+		searchQuery += quoteString(query)
 	}
 
 	// Add options
@@ -71,7 +204,12 @@ func FTSMatch(query string, options ...FTSSearchOptions) N1qlizer {
 	return Expr(searchQuery)
 }
 
-// FTSPhraseMatch creates a Full-Text Search phrase match expression
+// FTSPhraseMatch creates a Full-Text Search phrase match expression. query
+// is matched as one phrase: it is wrapped in the double quotes of the FTS
+// query string syntax, so FTSPhraseMatch("gaming laptop", opts) searches for
+// the query string "gaming laptop". One pair of double quotes the caller put
+// around query is removed rather than doubled; other double quotes in query
+// are matched literally.
 func FTSPhraseMatch(query string, options ...FTSSearchOptions) N1qlizer {
 	opts := FTSSearchOptions{}
 	if len(options) > 0 {
@@ -84,13 +222,13 @@ func FTSPhraseMatch(query string, options ...FTSSearchOptions) N1qlizer {
 
 	// Handle already quoted phrases to avoid double-quoting
 	queryToUse := query
-	if strings.HasPrefix(query, "\"") && strings.HasSuffix(query, "\"") {
-		// Remove the quotes since we'll add them in the SEARCH function
+	if len(query) >= 2 && strings.HasPrefix(query, "\"") && strings.HasSuffix(query, "\"") {
+		// Remove the quotes since ftsPhrase adds them
 		queryToUse = query[1 : len(query)-1]
 	}
 
 	// Build a direct search query
-	searchQuery := fmt.Sprintf("SEARCH(%s, \"%s\"", opts.IndexName, queryToUse)
+	searchQuery := fmt.Sprintf("SEARCH(%s, %s", opts.IndexName, quoteString(ftsPhrase(queryToUse)))
 
 	// Add options
 	params := make([]string, 0)
@@ -130,16 +268,18 @@ func FTSPrefixMatch(prefix string, options ...FTSSearchOptions) N1qlizer {
 	return FTSMatch(fmt.Sprintf("%s*", prefix), options...)
 }
 
-// FTSRangeMatch creates a Full-Text Search range match expression
+// FTSRangeMatch creates a Full-Text Search range match expression. Bounds are
+// rendered by type: numbers as is, time.Time values as quoted RFC 3339 dates
+// and strings as quoted terms. A nil bound leaves the range open on that side.
 func FTSRangeMatch(field string, min, max interface{}, options ...FTSSearchOptions) N1qlizer {
 	var rangeQuery string
 
 	if min != nil && max != nil {
-		rangeQuery = fmt.Sprintf("%s:[%v TO %v]", field, min, max)
+		rangeQuery = fmt.Sprintf("%s:[%s TO %s]", field, ftsRangeValue(min), ftsRangeValue(max))
 	} else if min != nil {
-		rangeQuery = fmt.Sprintf("%s:>=%v", field, min)
+		rangeQuery = fmt.Sprintf("%s:>=%s", field, ftsRangeValue(min))
 	} else if max != nil {
-		rangeQuery = fmt.Sprintf("%s:<=%v", field, max)
+		rangeQuery = fmt.Sprintf("%s:<=%s", field, ftsRangeValue(max))
 	} else {
 		return Expr("ERROR: At least one of min or max must be specified")
 	}
@@ -147,6 +287,81 @@ func FTSRangeMatch(field string, min, max interface{}, options ...FTSSearchOptio
 	return FTSMatch(rangeQuery, options...)
 }
 
+// FTSNumericRange creates a Full-Text Search numeric range match expression,
+// e.g. "price:[10 TO 20]".
+func FTSNumericRange(field string, min, max float64, opts FTSSearchOptions) N1qlizer {
+	return FTSRangeMatch(field, min, max, opts)
+}
+
+// FTSDateRange creates a Full-Text Search date range match expression with
+// RFC 3339 dates. A zero start or end leaves the range open on that side.
+func FTSDateRange(field string, start, end time.Time, opts FTSSearchOptions) N1qlizer {
+	var min, max interface{}
+	if !start.IsZero() {
+		min = start
+	}
+	if !end.IsZero() {
+		max = end
+	}
+	return FTSRangeMatch(field, min, max, opts)
+}
+
+// ftsRangeValue renders a bound of a range query.
+func ftsRangeValue(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return fmt.Sprintf("\"%s\"", v.Format(time.RFC3339))
+	case string:
+		return fmt.Sprintf("\"%s\"", strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v))
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// FTSGeoDistance creates a Full-Text Search geo distance expression, matching
+// documents whose geo point field lies within distance (e.g. "10km" or "5mi")
+// of the given location. The coordinates and distance are bound as args:
+//
+//	SEARCH(index, {"field": "geo", "location": {"lat": ?, "lon": ?}, "distance": ?})
+func FTSGeoDistance(field string, lat, lon float64, distance string, opts FTSSearchOptions) N1qlizer {
+	if opts.IndexName == "" {
+		return Expr("ERROR: FTS index name is required")
+	}
+
+	query := fmt.Sprintf(`{"field": %s, "location": {"lat": ?, "lon": ?}, "distance": ?}`, quoteString(field))
+	return ftsGeoSearch(query, []interface{}{lat, lon, distance}, opts)
+}
+
+// FTSGeoBoundingBox creates a Full-Text Search geo bounding box expression,
+// matching documents whose geo point field lies within the rectangle spanned
+// by its top left and bottom right corners. The coordinates are bound as args:
+//
+//	SEARCH(index, {"field": "geo", "top_left": {"lat": ?, "lon": ?}, "bottom_right": {"lat": ?, "lon": ?}})
+func FTSGeoBoundingBox(field string, topLeftLat, topLeftLon, bottomRightLat, bottomRightLon float64, opts FTSSearchOptions) N1qlizer {
+	if opts.IndexName == "" {
+		return Expr("ERROR: FTS index name is required")
+	}
+
+	query := fmt.Sprintf(`{"field": %s, "top_left": {"lat": ?, "lon": ?}, "bottom_right": {"lat": ?, "lon": ?}}`, quoteString(field))
+	return ftsGeoSearch(query, []interface{}{topLeftLat, topLeftLon, bottomRightLat, bottomRightLon}, opts)
+}
+
+// ftsGeoSearch wraps a geo query object in a SEARCH call on the index of opts.
+func ftsGeoSearch(query string, args []interface{}, opts FTSSearchOptions) N1qlizer {
+	searchQuery := fmt.Sprintf("SEARCH(%s, %s)", opts.IndexName, query)
+
+	// Add scoring if specified
+	if opts.Score != "" {
+		searchQuery = fmt.Sprintf("%s AS %s", searchQuery, opts.Score)
+	}
+
+	return Expr(searchQuery, args...)
+}
+
 // FTSConjunction creates a conjunction (AND) of multiple FTS expressions
 func FTSConjunction(expressions ...N1qlizer) N1qlizer {
 	if len(expressions) == 0 {
@@ -199,16 +414,24 @@ func FTSDisjunction(expressions ...N1qlizer) N1qlizer {
 	return Expr(fmt.Sprintf("(%s)", strings.Join(queries, " OR ")), args...)
 }
 
-// FTSSearchService creates an expression to use Couchbase's dedicated search service
-func FTSSearchService(indexName, query string, options ...interface{}) N1qlizer {
-	var fieldsVal string
-	var limit, offset int
-	var highlightStyle, scoreField string
-	var explain bool
+// FTSServiceOptions are the options of a search service request built with
+// FTSSearchServiceOpts.
+type FTSServiceOptions struct {
+	Fields    []string      // Fields to return
+	Limit     int           // Maximum number of hits
+	Offset    int           // Number of hits to skip
+	Highlight string        // Highlight style, e.g. "html" or "ansi"
+	Score     string        // Name of the field to store the score in
+	Explain   bool          // Whether to explain the scoring
+	Facets    []FTSFacet    // Facets to compute
+	Sort      []interface{} // Sort specs, strings or FTSGeoSort values
+}
 
-	if indexName == "" {
-		return Expr("ERROR: FTS index name is required")
-	}
+// FTSSearchService creates an expression to use Couchbase's dedicated search service.
+// Options are given as key/value pairs, e.g. "limit", 10; keys and values of
+// the wrong type are ignored. See FTSSearchServiceOpts for a typed alternative.
+func FTSSearchService(indexName, query string, options ...interface{}) N1qlizer {
+	var opts FTSServiceOptions
 
 	// Process options
 	for i := 0; i < len(options); i += 2 {
@@ -226,72 +449,141 @@ func FTSSearchService(indexName, query string, options ...interface{}) N1qlizer
 		switch key {
 		case "fields":
 			if fields, ok := value.([]string); ok {
-				fieldsStr := make([]string, len(fields))
-				for i, field := range fields {
-					fieldsStr[i] = fmt.Sprintf("\"%s\"", field)
-				}
-				fieldsVal = fmt.Sprintf("[%s]", strings.Join(fieldsStr, ", "))
+				opts.Fields = fields
 			}
 		case "limit":
 			if v, ok := value.(int); ok {
-				limit = v
+				opts.Limit = v
 			}
 		case "offset":
 			if v, ok := value.(int); ok {
-				offset = v
+				opts.Offset = v
 			}
 		case "highlight":
 			if style, ok := value.(string); ok {
-				highlightStyle = style
+				opts.Highlight = style
 			}
 		case "score":
 			if field, ok := value.(string); ok {
-				scoreField = field
+				opts.Score = field
 			}
 		case "explain":
 			if v, ok := value.(bool); ok {
-				explain = v
+				opts.Explain = v
+			}
+		case "facets":
+			if facets, ok := value.([]FTSFacet); ok {
+				opts.Facets = facets
+			}
+		case "sort":
+			switch v := value.(type) {
+			case []string:
+				opts.Sort = make([]interface{}, len(v))
+				for i, spec := range v {
+					opts.Sort[i] = spec
+				}
+			case []interface{}:
+				opts.Sort = v
+			default:
+				return Expr(fmt.Sprintf("ERROR: FTS sort must be a []string or []interface{}, got %T", value))
 			}
 		}
 	}
 
+	return FTSSearchServiceOpts(indexName, query, opts)
+}
+
+// FTSSearchServiceOpts creates an expression to use Couchbase's dedicated
+// search service with typed options.
+func FTSSearchServiceOpts(indexName, query string, opts FTSServiceOptions) N1qlizer {
+	if indexName == "" {
+		return Expr("ERROR: FTS index name is required")
+	}
+
 	// Build the SEARCH function call
 	searchArgs := make([]string, 0)
 	searchArgs = append(searchArgs, fmt.Sprintf("index: %s", indexName))
 	searchArgs = append(searchArgs, fmt.Sprintf("query: \"%s\"", query))
 
-	if fieldsVal != "" {
-		searchArgs = append(searchArgs, fmt.Sprintf("fields: %s", fieldsVal))
+	if len(opts.Fields) > 0 {
+		fieldsStr := make([]string, len(opts.Fields))
+		for i, field := range opts.Fields {
+			fieldsStr[i] = fmt.Sprintf("\"%s\"", field)
+		}
+		searchArgs = append(searchArgs, fmt.Sprintf("fields: [%s]", strings.Join(fieldsStr, ", ")))
 	}
 
-	if limit > 0 {
-		searchArgs = append(searchArgs, fmt.Sprintf("limit: %d", limit))
+	if opts.Limit > 0 {
+		searchArgs = append(searchArgs, fmt.Sprintf("limit: %d", opts.Limit))
 	}
 
-	if offset > 0 {
-		searchArgs = append(searchArgs, fmt.Sprintf("offset: %d", offset))
+	if opts.Offset > 0 {
+		searchArgs = append(searchArgs, fmt.Sprintf("offset: %d", opts.Offset))
 	}
 
-	if highlightStyle != "" {
-		searchArgs = append(searchArgs, fmt.Sprintf("highlight: {\"style\":\"%s\"}", highlightStyle))
+	if opts.Highlight != "" {
+		searchArgs = append(searchArgs, fmt.Sprintf("highlight: {\"style\":\"%s\"}", opts.Highlight))
 	}
 
-	if explain {
+	if opts.Explain {
 		searchArgs = append(searchArgs, "explain: true")
 	}
 
+	if len(opts.Facets) > 0 {
+		facets, err := ftsFacets(opts.Facets)
+		if err != nil {
+			return Expr(fmt.Sprintf("ERROR: %s", err.Error()))
+		}
+		searchArgs = append(searchArgs, fmt.Sprintf("facets: %s", facets))
+	}
+
+	if len(opts.Sort) > 0 {
+		sort, err := ftsSort(opts.Sort)
+		if err != nil {
+			return Expr(fmt.Sprintf("ERROR: %s", err.Error()))
+		}
+		searchArgs = append(searchArgs, fmt.Sprintf("sort: %s", sort))
+	}
+
 	searchCall := fmt.Sprintf("SEARCH({%s})", strings.Join(searchArgs, ", "))
 
-	if scoreField != "" {
-		searchCall = fmt.Sprintf("%s AS %s", searchCall, scoreField)
+	if opts.Score != "" {
+		searchCall = fmt.Sprintf("%s AS %s", searchCall, opts.Score)
 	}
 
 	return Expr(searchCall)
 }
 
+// SearchScore returns a SEARCH_SCORE(indexRef) projection, the relevance
+// score of a document matched by a SEARCH on indexRef in the WHERE clause.
+// indexRef is the keyspace alias or the "out" name given to the SEARCH.
+func SearchScore(indexRef string) N1qlizer {
+	return Expr(fmt.Sprintf("SEARCH_SCORE(%s)", indexRef))
+}
+
+// SearchMeta returns a SEARCH_META(indexRef) projection, the search metadata
+// (such as the score and locations) of a document matched by a SEARCH.
+func SearchMeta(indexRef string) N1qlizer {
+	return Expr(fmt.Sprintf("SEARCH_META(%s)", indexRef))
+}
+
 // SelectBuilder method for FTS
 
-// WithSearch adds a SEARCH clause to the WHERE part of a query
+// WithSearch adds a SEARCH clause to the WHERE part of a query.
+//
+// There are two ways to run a search: with WithSearch alone the query service
+// picks the access path, which may be a GSI index scan with SEARCH evaluated
+// as a filter. With WithSearchIndex the query is hinted to use an FTS index,
+// so that the Search service evaluates the SEARCH predicate and only the
+// matching documents are fetched.
 func (b SelectBuilder) WithSearch(search N1qlizer) SelectBuilder {
 	return b.Where(search)
 }
+
+// WithSearchIndex adds a SEARCH clause to the WHERE part of a query and a
+// "USE INDEX (`indexName` USING FTS)" hint after the FROM keyspace, making the
+// dedicated Search service the access path of the query. An empty indexName
+// renders "USE INDEX (USING FTS)", letting the query service pick the index.
+func (b SelectBuilder) WithSearchIndex(indexName string, search N1qlizer) SelectBuilder {
+	return b.UseIndex(UseIndexFTS(indexName)).Where(search)
+}
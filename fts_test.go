@@ -1,8 +1,10 @@
 package n1qlizer
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestFTSMatch(t *testing.T) {
@@ -127,8 +129,10 @@ func TestFTSPhraseMatch(t *testing.T) {
 			t.Fatalf("Failed to build FTS phrase match: %v", err)
 		}
 
-		if !strings.Contains(sql, "\"gaming laptop\"") {
-			t.Errorf("Expected phrase to be quoted, got '%s'", sql)
+		// The phrase is wrapped in FTS quotes inside the N1QL string
+		expected := `SEARCH(product_index, "\"gaming laptop\"")`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
 		}
 
 		if len(args) != 0 {
@@ -147,6 +151,11 @@ func TestFTSPhraseMatch(t *testing.T) {
 		if strings.Contains(sql, "\"\"gaming laptop\"\"") {
 			t.Errorf("Expected no double-quoting, got '%s'", sql)
 		}
+
+		expected := `SEARCH(product_index, "\"gaming laptop\"")`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
+		}
 	})
 }
 
@@ -236,6 +245,38 @@ func TestFTSRangeMatch(t *testing.T) {
 		}
 	})
 
+	t.Run("Typed bounds", func(t *testing.T) {
+		options := FTSSearchOptions{
+			IndexName: "product_index",
+		}
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+		testCases := []struct {
+			name     string
+			expr     N1qlizer
+			expected string
+		}{
+			{"Floats", FTSRangeMatch("price", 9.99, 1e6, options), `price:[9.99 TO 1000000]`},
+			{"Strings", FTSRangeMatch("name", "a", `say "z"`, options), `name:[\"a\" TO \"say \\\"z\\\"\"]`},
+			{"Times", FTSRangeMatch("created", start, nil, options), `created:>=\"2024-01-01T00:00:00Z\"`},
+			{"FTSNumericRange", FTSNumericRange("price", 10, 20.5, options), `price:[10 TO 20.5]`},
+			{"FTSDateRange", FTSDateRange("created", start, end, options), `created:[\"2024-01-01T00:00:00Z\" TO \"2024-06-30T12:00:00Z\"]`},
+			{"Open FTSDateRange", FTSDateRange("created", time.Time{}, end, options), `created:<=\"2024-06-30T12:00:00Z\"`},
+		}
+
+		for _, tc := range testCases {
+			sql, _, err := tc.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("%s: failed to build FTS range match: %v", tc.name, err)
+			}
+
+			if !strings.Contains(sql, tc.expected) {
+				t.Errorf("%s: expected '%s', got '%s'", tc.name, tc.expected, sql)
+			}
+		}
+	})
+
 	t.Run("No bounds", func(t *testing.T) {
 		options := FTSSearchOptions{
 			IndexName: "product_index",
@@ -392,6 +433,101 @@ func TestFTSSearchService(t *testing.T) {
 		}
 	})
 
+	t.Run("With facets", func(t *testing.T) {
+		cheap, expensive := 100.0, 1000.0
+		expr := FTSSearchService("product_index", "laptop",
+			"facets", []FTSFacet{
+				{Name: "brands", Field: "brand", Size: 5},
+				{Name: "prices", Field: "price", Size: 3, NumericRanges: []FTSFacetNumericRange{
+					{Name: "cheap", Max: &cheap},
+					{Name: "mid", Min: &cheap, Max: &expensive},
+					{Name: "expensive", Min: &expensive},
+				}},
+				{Name: "added", Field: "created", Size: 2, DateRanges: []FTSFacetDateRange{
+					{Name: "new?", Start: "2024-01-01T00:00:00Z"},
+				}},
+			})
+		sql, args, err := expr.ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build FTS search service: %v", err)
+		}
+
+		expected := `facets: {` +
+			`"added":{"field":"created","size":2,"date_ranges":[{"name":"new\u003f","start":"2024-01-01T00:00:00Z"}]},` +
+			`"brands":{"field":"brand","size":5},` +
+			`"prices":{"field":"price","size":3,"numeric_ranges":[{"name":"cheap","max":100},{"name":"mid","min":100,"max":1000},{"name":"expensive","min":1000}]}}`
+		if !strings.Contains(sql, expected) {
+			t.Errorf("Expected facets %s, got '%s'", expected, sql)
+		}
+
+		if len(args) != 0 {
+			t.Errorf("Expected empty args, got %v", args)
+		}
+
+		sql, _, _ = FTSSearchService("product_index", "laptop", "facets", []FTSFacet{{Name: "brands"}}).ToN1ql()
+		if !strings.Contains(sql, "ERROR") {
+			t.Errorf("Expected error for facet without field, got '%s'", sql)
+		}
+	})
+
+	t.Run("With sort", func(t *testing.T) {
+		expr := FTSSearchService("product_index", "laptop",
+			"sort", []string{"-_score", "price"})
+		sql, _, _ := expr.ToN1ql()
+
+		if !strings.Contains(sql, `sort: ["-_score","price"]`) {
+			t.Errorf("Expected sort specification, got '%s'", sql)
+		}
+
+		expr = FTSSearchService("store_index", "coffee",
+			"sort", []interface{}{FTSGeoSort{Field: "geo", Lat: 52.37, Lon: 4.89, Unit: "km"}, "-_score"})
+		sql, _, _ = expr.ToN1ql()
+
+		expected := `sort: [{"by":"geo_distance","field":"geo","location":{"lat":52.37,"lon":4.89},"unit":"km"},"-_score"]`
+		if !strings.Contains(sql, expected) {
+			t.Errorf("Expected %s, got '%s'", expected, sql)
+		}
+
+		sql, _, _ = FTSSearchService("product_index", "laptop", "sort", []interface{}{42}).ToN1ql()
+		if !strings.Contains(sql, "ERROR") {
+			t.Errorf("Expected error for invalid sort spec, got '%s'", sql)
+		}
+	})
+
+	t.Run("Typed options", func(t *testing.T) {
+		opts := FTSServiceOptions{
+			Fields:    []string{"name"},
+			Limit:     10,
+			Offset:    20,
+			Highlight: "html",
+			Score:     "relevance",
+			Explain:   true,
+			Facets:    []FTSFacet{{Name: "brands", Field: "brand", Size: 5}},
+			Sort:      []interface{}{"-_score"},
+		}
+		sql, args, err := FTSSearchServiceOpts("product_index", "laptop", opts).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build FTS search service: %v", err)
+		}
+
+		expected := `SEARCH({index: product_index, query: "laptop", fields: ["name"], limit: 10, offset: 20, ` +
+			`highlight: {"style":"html"}, explain: true, facets: {"brands":{"field":"brand","size":5}}, sort: ["-_score"]}) AS relevance`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
+		}
+
+		if len(args) != 0 {
+			t.Errorf("Expected empty args, got %v", args)
+		}
+
+		variadic, _, _ := FTSSearchService("product_index", "laptop",
+			"fields", []string{"name"}, "limit", 10, "offset", 20, "highlight", "html", "score", "relevance",
+			"explain", true, "facets", opts.Facets, "sort", []string{"-_score"}).ToN1ql()
+		if variadic != sql {
+			t.Errorf("Expected variadic form to match typed form, got '%s'", variadic)
+		}
+	})
+
 	t.Run("Missing index name", func(t *testing.T) {
 		expr := FTSSearchService("", "laptop")
 		sql, _, _ := expr.ToN1ql()
@@ -428,4 +564,168 @@ func TestWithSearch(t *testing.T) {
 			t.Errorf("Expected empty args, got %v", args)
 		}
 	})
+
+	t.Run("WithSearch with score and meta columns", func(t *testing.T) {
+		sql, _, err := Select("p.name").
+			ColumnAs(SearchScore("p"), "score").
+			ColumnAs(SearchMeta("p"), "meta").
+			From("products p").
+			WithSearch(FTSMatch("laptop", FTSSearchOptions{IndexName: "p"})).
+			OrderBy("score DESC").
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build select with search: %v", err)
+		}
+
+		expected := `SELECT p.name, (SEARCH_SCORE(p)) AS score, (SEARCH_META(p)) AS meta FROM products p WHERE SEARCH(p, "laptop") ORDER BY score DESC`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
+		}
+	})
+}
+
+func TestWithSearchIndex(t *testing.T) {
+	sql, args, err := Select("p.name").
+		From("products p").
+		WithSearchIndex("product_fts", FTSMatch("laptop", FTSSearchOptions{IndexName: "p"})).
+		Where("p.price < ?", 1000).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build select with search: %v", err)
+	}
+
+	expected := "SELECT p.name FROM products p USE INDEX (`product_fts` USING FTS) WHERE SEARCH(p, \"laptop\") AND p.price < ?"
+	if sql != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != 1000 {
+		t.Errorf("Wrong args: %v", args)
+	}
+
+	sql, _, err = Select("*").From("products p").WithSearchIndex("", FTSMatch("laptop", FTSSearchOptions{IndexName: "p"})).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build select with search: %v", err)
+	}
+
+	if sql != "SELECT * FROM products p USE INDEX (USING FTS) WHERE SEARCH(p, \"laptop\")" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+}
+
+func ExampleSearchScore() {
+	sql, _ := Select("p.name").
+		ColumnAs(SearchScore("p"), "score").
+		From("products p").
+		WithSearch(FTSMatch("laptop", FTSSearchOptions{IndexName: "p"})).
+		OrderBy("score DESC").
+		MustN1ql()
+
+	fmt.Println(sql)
+	// Output:
+	// SELECT p.name, (SEARCH_SCORE(p)) AS score FROM products p WHERE SEARCH(p, "laptop") ORDER BY score DESC
+}
+
+func TestFTSGeo(t *testing.T) {
+	t.Run("Geo distance", func(t *testing.T) {
+		expr := FTSGeoDistance("geo", 52.37, 4.89, "10km", FTSSearchOptions{IndexName: "stores_index"})
+		sql, args, err := expr.ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build FTS geo distance: %v", err)
+		}
+
+		expected := `SEARCH(stores_index, {"field": "geo", "location": {"lat": ?, "lon": ?}, "distance": ?})`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
+		}
+
+		if len(args) != 3 || args[0] != 52.37 || args[1] != 4.89 || args[2] != "10km" {
+			t.Errorf("Wrong args: %v", args)
+		}
+	})
+
+	t.Run("Geo bounding box", func(t *testing.T) {
+		expr := FTSGeoBoundingBox("geo", 53.0, 4.0, 52.0, 5.0, FTSSearchOptions{IndexName: "stores_index"})
+		sql, args, err := Select("s.name").From("stores s").WithSearch(expr).PlaceholderFormat(Dollar).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		expected := `SELECT s.name FROM stores s WHERE SEARCH(stores_index, {"field": "geo", "top_left": {"lat": $1, "lon": $2}, "bottom_right": {"lat": $3, "lon": $4}})`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
+		}
+
+		if len(args) != 4 || args[0] != 53.0 || args[3] != 5.0 {
+			t.Errorf("Wrong args: %v", args)
+		}
+	})
+
+	t.Run("Missing index name", func(t *testing.T) {
+		sql, _, _ := FTSGeoDistance("geo", 0, 0, "1km", FTSSearchOptions{}).ToN1ql()
+		if !strings.Contains(sql, "ERROR") {
+			t.Errorf("Expected error expression, got '%s'", sql)
+		}
+	})
+}
+
+func TestFTSEscaping(t *testing.T) {
+	t.Run("EscapeFTS", func(t *testing.T) {
+		got := EscapeFTS(`c++ (beta): "v2" a/b\c`)
+		expected := `c\+\+ \(beta\)\: \"v2\" a\/b\\c`
+		if got != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, got)
+		}
+	})
+
+	t.Run("Field names", func(t *testing.T) {
+		options := FTSSearchOptions{
+			IndexName: "product_index",
+			Fields:    []string{"specs:cpu"},
+		}
+		sql, args, err := FTSMatch("i7", options).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build FTS match: %v", err)
+		}
+
+		expected := `SEARCH(product_index, "specs\\:cpu:i7")`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
+		}
+
+		if len(args) != 0 {
+			t.Errorf("Expected empty args, got %v", args)
+		}
+	})
+
+	t.Run("Phrase", func(t *testing.T) {
+		options := FTSSearchOptions{
+			IndexName: "product_index",
+		}
+		sql, _, err := FTSPhraseMatch(`say "hi" (now)?`, options).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build FTS phrase match: %v", err)
+		}
+
+		// The ? is written as a unicode escape so that it is not taken for a placeholder
+		expected := `SEARCH(product_index, "\"say \\\"hi\\\" (now)\u003f\"")`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
+		}
+	})
+
+	t.Run("Query literal", func(t *testing.T) {
+		options := FTSSearchOptions{
+			IndexName: "product_index",
+		}
+		sql, _, err := FTSMatch(`laptop") OR 1=1 --`, options).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build FTS match: %v", err)
+		}
+
+		expected := `SEARCH(product_index, "laptop\") OR 1=1 --")`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
+		}
+	})
 }
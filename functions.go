@@ -0,0 +1,36 @@
+package n1qlizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArrayDistinct returns an ARRAY_DISTINCT function call, the distinct
+// elements of arr.
+func ArrayDistinct(arr string) N1qlizer {
+	return newPart(fmt.Sprintf("ARRAY_DISTINCT(%s)", arr))
+}
+
+// ArraySort returns an ARRAY_SORT function call, the elements of arr in
+// N1QL collation order.
+func ArraySort(arr string) N1qlizer {
+	return newPart(fmt.Sprintf("ARRAY_SORT(%s)", arr))
+}
+
+// ArrayReverse returns an ARRAY_REVERSE function call, the elements of arr
+// in reverse order.
+func ArrayReverse(arr string) N1qlizer {
+	return newPart(fmt.Sprintf("ARRAY_REVERSE(%s)", arr))
+}
+
+// ArrayConcat returns an ARRAY_CONCAT function call, the elements of all the
+// given arrays in order.
+func ArrayConcat(arrs ...string) N1qlizer {
+	return newPart(fmt.Sprintf("ARRAY_CONCAT(%s)", strings.Join(arrs, ", ")))
+}
+
+// ArrayLength returns an ARRAY_LENGTH function call, the number of elements
+// of arr.
+func ArrayLength(arr string) N1qlizer {
+	return newPart(fmt.Sprintf("ARRAY_LENGTH(%s)", arr))
+}
@@ -0,0 +1,55 @@
+package n1qlizer
+
+import (
+	"testing"
+)
+
+func TestArrayFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     N1qlizer
+		expected string
+	}{
+		{"ArrayDistinct", ArrayDistinct("u.tags"), "ARRAY_DISTINCT(u.tags)"},
+		{"ArraySort", ArraySort("u.tags"), "ARRAY_SORT(u.tags)"},
+		{"ArrayReverse", ArrayReverse("u.tags"), "ARRAY_REVERSE(u.tags)"},
+		{"ArrayConcat", ArrayConcat("u.tags", "u.labels"), "ARRAY_CONCAT(u.tags, u.labels)"},
+		{"ArrayLength", ArrayLength("u.tags"), "ARRAY_LENGTH(u.tags)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, sql)
+			}
+
+			if len(args) != 0 {
+				t.Errorf("Expected empty args, got %v", args)
+			}
+		})
+	}
+
+	sql, args, err := Select().
+		ColumnAs(ArrayDistinct("ARRAY_CONCAT(u.tags, u.labels)"), "allTags").
+		From("users u").
+		Where(Expr("? > ?", ArrayLength("u.tags"), 2)).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT (ARRAY_DISTINCT(ARRAY_CONCAT(u.tags, u.labels))) AS allTags FROM users u WHERE ARRAY_LENGTH(u.tags) > ?"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{2}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
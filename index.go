@@ -0,0 +1,521 @@
+package n1qlizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// createIndexData stores the state of a CREATE INDEX statement as it is built
+type createIndexData struct {
+	PlaceholderFormat PlaceholderFormat
+	RunWith           QueryRunner
+	Name              string
+	Namespace         string
+	Keyspace          string
+	Fields            []string
+	WhereParts        []N1qlizer
+	Using             string
+	Options           map[string]any
+}
+
+func (d *createIndexData) ToN1ql() (sqlStr string, args []any, err error) {
+	if len(d.Name) == 0 {
+		err = fmt.Errorf("create index statements must specify an index name")
+		return
+	}
+	if len(d.Keyspace) == 0 {
+		err = fmt.Errorf("create index statements must specify a keyspace")
+		return
+	}
+	if len(d.Fields) == 0 {
+		err = fmt.Errorf("create index statements must have at least one indexed field")
+		return
+	}
+
+	sql := &bytes.Buffer{}
+
+	fmt.Fprintf(sql, "CREATE INDEX `%s` ON %s(%s)", d.Name, namespaceKeyspaces(d.Keyspace, d.Namespace), strings.Join(d.Fields, ", "))
+
+	if len(d.WhereParts) > 0 {
+		sql.WriteString(" WHERE ")
+		args, err = buildClauses(d.WhereParts, sql, " AND ", args)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(d.Using) > 0 {
+		sql.WriteString(" USING ")
+		sql.WriteString(d.Using)
+	}
+
+	if err = writeWithOptions(sql, d.Options); err != nil {
+		return
+	}
+
+	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sql.String())
+	return
+}
+
+// writeWithOptions writes a WITH clause rendering options as a JSON object.
+func writeWithOptions(sql *bytes.Buffer, options map[string]any) error {
+	if len(options) == 0 {
+		return nil
+	}
+
+	opts, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	sql.WriteString(" WITH ")
+	sql.Write(opts)
+	return nil
+}
+
+// CreateIndexBuilder builds CREATE INDEX statements.
+type CreateIndexBuilder Builder
+
+func init() {
+	Register(CreateIndexBuilder{}, createIndexData{})
+}
+
+// PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
+// query.
+func (b CreateIndexBuilder) PlaceholderFormat(f PlaceholderFormat) CreateIndexBuilder {
+	return Set[CreateIndexBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
+}
+
+// RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
+func (b CreateIndexBuilder) RunWith(runner QueryRunner) CreateIndexBuilder {
+	return Set[CreateIndexBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// Execute builds and executes the query.
+func (b CreateIndexBuilder) Execute() (QueryResult, error) {
+	data := GetStruct(b).(createIndexData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+	return ExecuteWith(data.RunWith, b)
+}
+
+// ToN1ql builds the query into a N1QL string and bound args.
+func (b CreateIndexBuilder) ToN1ql() (string, []any, error) {
+	data := GetStruct(b).(createIndexData)
+	return data.ToN1ql()
+}
+
+// MustN1ql builds the query into a N1QL string and bound args.
+//
+// MustN1ql panics if there are any errors.
+func (b CreateIndexBuilder) MustN1ql() (string, []any) {
+	sql, args, err := b.ToN1ql()
+	if err != nil {
+		panic(err)
+	}
+	return sql, args
+}
+
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(CreateIndexBuilder) CreateIndexBuilder.
+func (b CreateIndexBuilder) Apply(fns ...func(CreateIndexBuilder) CreateIndexBuilder) CreateIndexBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b CreateIndexBuilder) ApplyIf(cond bool, fn func(CreateIndexBuilder) CreateIndexBuilder) CreateIndexBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Name sets the name of the index.
+func (b CreateIndexBuilder) Name(name string) CreateIndexBuilder {
+	return Set[CreateIndexBuilder, string](b, "Name", name)
+}
+
+// Keyspace sets the keyspace the index is created on.
+func (b CreateIndexBuilder) Keyspace(keyspace string) CreateIndexBuilder {
+	return Set[CreateIndexBuilder, string](b, "Keyspace", keyspace)
+}
+
+// On sets the indexed fields or expressions.
+func (b CreateIndexBuilder) On(fields ...string) CreateIndexBuilder {
+	return Set[CreateIndexBuilder, []string](b, "Fields", fields)
+}
+
+// Where adds an expression to the WHERE clause of a partial index.
+// A nil pred adds nothing.
+func (b CreateIndexBuilder) Where(pred any, args ...any) CreateIndexBuilder {
+	if pred == nil {
+		return b
+	}
+	return Append[CreateIndexBuilder, N1qlizer](b, "WhereParts", Expr(pred, args...))
+}
+
+// Using sets the index type, e.g. "GSI".
+func (b CreateIndexBuilder) Using(indexType string) CreateIndexBuilder {
+	return Set[CreateIndexBuilder, string](b, "Using", indexType)
+}
+
+// With sets the WITH options of the index, such as "defer_build" or
+// "num_replica". The options are rendered as a JSON object.
+func (b CreateIndexBuilder) With(options map[string]any) CreateIndexBuilder {
+	return Set[CreateIndexBuilder, map[string]any](b, "Options", options)
+}
+
+// dropIndexData stores the state of a DROP INDEX statement as it is built
+type dropIndexData struct {
+	PlaceholderFormat PlaceholderFormat
+	RunWith           QueryRunner
+	Name              string
+	Namespace         string
+	Keyspace          string
+	Using             string
+}
+
+func (d *dropIndexData) ToN1ql() (sqlStr string, args []any, err error) {
+	if len(d.Name) == 0 {
+		err = fmt.Errorf("drop index statements must specify an index name")
+		return
+	}
+	if len(d.Keyspace) == 0 {
+		err = fmt.Errorf("drop index statements must specify a keyspace")
+		return
+	}
+
+	sql := &bytes.Buffer{}
+
+	fmt.Fprintf(sql, "DROP INDEX `%s` ON %s", d.Name, namespaceKeyspaces(d.Keyspace, d.Namespace))
+
+	if len(d.Using) > 0 {
+		sql.WriteString(" USING ")
+		sql.WriteString(d.Using)
+	}
+
+	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sql.String())
+	return
+}
+
+// DropIndexBuilder builds DROP INDEX statements.
+type DropIndexBuilder Builder
+
+func init() {
+	Register(DropIndexBuilder{}, dropIndexData{})
+}
+
+// PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
+// query.
+func (b DropIndexBuilder) PlaceholderFormat(f PlaceholderFormat) DropIndexBuilder {
+	return Set[DropIndexBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
+}
+
+// RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
+func (b DropIndexBuilder) RunWith(runner QueryRunner) DropIndexBuilder {
+	return Set[DropIndexBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// Execute builds and executes the query.
+func (b DropIndexBuilder) Execute() (QueryResult, error) {
+	data := GetStruct(b).(dropIndexData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+	return ExecuteWith(data.RunWith, b)
+}
+
+// ToN1ql builds the query into a N1QL string and bound args.
+func (b DropIndexBuilder) ToN1ql() (string, []any, error) {
+	data := GetStruct(b).(dropIndexData)
+	return data.ToN1ql()
+}
+
+// MustN1ql builds the query into a N1QL string and bound args.
+//
+// MustN1ql panics if there are any errors.
+func (b DropIndexBuilder) MustN1ql() (string, []any) {
+	sql, args, err := b.ToN1ql()
+	if err != nil {
+		panic(err)
+	}
+	return sql, args
+}
+
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(DropIndexBuilder) DropIndexBuilder.
+func (b DropIndexBuilder) Apply(fns ...func(DropIndexBuilder) DropIndexBuilder) DropIndexBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b DropIndexBuilder) ApplyIf(cond bool, fn func(DropIndexBuilder) DropIndexBuilder) DropIndexBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Name sets the name of the index.
+func (b DropIndexBuilder) Name(name string) DropIndexBuilder {
+	return Set[DropIndexBuilder, string](b, "Name", name)
+}
+
+// Keyspace sets the keyspace the index belongs to.
+func (b DropIndexBuilder) Keyspace(keyspace string) DropIndexBuilder {
+	return Set[DropIndexBuilder, string](b, "Keyspace", keyspace)
+}
+
+// Using sets the index type, e.g. "GSI".
+func (b DropIndexBuilder) Using(indexType string) DropIndexBuilder {
+	return Set[DropIndexBuilder, string](b, "Using", indexType)
+}
+
+// createPrimaryIndexData stores the state of a CREATE PRIMARY INDEX statement as it is built
+type createPrimaryIndexData struct {
+	PlaceholderFormat PlaceholderFormat
+	RunWith           QueryRunner
+	Name              string
+	Namespace         string
+	Keyspace          string
+	Using             string
+	Options           map[string]any
+}
+
+func (d *createPrimaryIndexData) ToN1ql() (sqlStr string, args []any, err error) {
+	if len(d.Keyspace) == 0 {
+		err = fmt.Errorf("create primary index statements must specify a keyspace")
+		return
+	}
+
+	sql := &bytes.Buffer{}
+
+	sql.WriteString("CREATE PRIMARY INDEX ")
+	if len(d.Name) > 0 {
+		fmt.Fprintf(sql, "`%s` ", d.Name)
+	}
+	sql.WriteString("ON ")
+	sql.WriteString(namespaceKeyspaces(d.Keyspace, d.Namespace))
+
+	if len(d.Using) > 0 {
+		sql.WriteString(" USING ")
+		sql.WriteString(d.Using)
+	}
+
+	if err = writeWithOptions(sql, d.Options); err != nil {
+		return
+	}
+
+	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sql.String())
+	return
+}
+
+// CreatePrimaryIndexBuilder builds CREATE PRIMARY INDEX statements.
+type CreatePrimaryIndexBuilder Builder
+
+func init() {
+	Register(CreatePrimaryIndexBuilder{}, createPrimaryIndexData{})
+}
+
+// PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
+// query.
+func (b CreatePrimaryIndexBuilder) PlaceholderFormat(f PlaceholderFormat) CreatePrimaryIndexBuilder {
+	return Set[CreatePrimaryIndexBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
+}
+
+// RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
+func (b CreatePrimaryIndexBuilder) RunWith(runner QueryRunner) CreatePrimaryIndexBuilder {
+	return Set[CreatePrimaryIndexBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// Execute builds and executes the query.
+func (b CreatePrimaryIndexBuilder) Execute() (QueryResult, error) {
+	data := GetStruct(b).(createPrimaryIndexData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+	return ExecuteWith(data.RunWith, b)
+}
+
+// ToN1ql builds the query into a N1QL string and bound args.
+func (b CreatePrimaryIndexBuilder) ToN1ql() (string, []any, error) {
+	data := GetStruct(b).(createPrimaryIndexData)
+	return data.ToN1ql()
+}
+
+// MustN1ql builds the query into a N1QL string and bound args.
+//
+// MustN1ql panics if there are any errors.
+func (b CreatePrimaryIndexBuilder) MustN1ql() (string, []any) {
+	sql, args, err := b.ToN1ql()
+	if err != nil {
+		panic(err)
+	}
+	return sql, args
+}
+
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(CreatePrimaryIndexBuilder) CreatePrimaryIndexBuilder.
+func (b CreatePrimaryIndexBuilder) Apply(fns ...func(CreatePrimaryIndexBuilder) CreatePrimaryIndexBuilder) CreatePrimaryIndexBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b CreatePrimaryIndexBuilder) ApplyIf(cond bool, fn func(CreatePrimaryIndexBuilder) CreatePrimaryIndexBuilder) CreatePrimaryIndexBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Name sets an optional name for the primary index.
+func (b CreatePrimaryIndexBuilder) Name(name string) CreatePrimaryIndexBuilder {
+	return Set[CreatePrimaryIndexBuilder, string](b, "Name", name)
+}
+
+// Keyspace sets the keyspace the primary index is created on.
+func (b CreatePrimaryIndexBuilder) Keyspace(keyspace string) CreatePrimaryIndexBuilder {
+	return Set[CreatePrimaryIndexBuilder, string](b, "Keyspace", keyspace)
+}
+
+// Using sets the index type, e.g. "GSI".
+func (b CreatePrimaryIndexBuilder) Using(indexType string) CreatePrimaryIndexBuilder {
+	return Set[CreatePrimaryIndexBuilder, string](b, "Using", indexType)
+}
+
+// With sets the WITH options of the index, such as "defer_build". The
+// options are rendered as a JSON object.
+func (b CreatePrimaryIndexBuilder) With(options map[string]any) CreatePrimaryIndexBuilder {
+	return Set[CreatePrimaryIndexBuilder, map[string]any](b, "Options", options)
+}
+
+// buildIndexData stores the state of a BUILD INDEX statement as it is built
+type buildIndexData struct {
+	PlaceholderFormat PlaceholderFormat
+	RunWith           QueryRunner
+	Namespace         string
+	Keyspace          string
+	Names             []string
+	Using             string
+}
+
+func (d *buildIndexData) ToN1ql() (sqlStr string, args []any, err error) {
+	if len(d.Keyspace) == 0 {
+		err = fmt.Errorf("build index statements must specify a keyspace")
+		return
+	}
+	if len(d.Names) == 0 {
+		err = fmt.Errorf("build index statements must name at least one index")
+		return
+	}
+
+	quoted := make([]string, len(d.Names))
+	for i, name := range d.Names {
+		quoted[i] = fmt.Sprintf("`%s`", name)
+	}
+
+	sql := &bytes.Buffer{}
+
+	fmt.Fprintf(sql, "BUILD INDEX ON %s(%s)", namespaceKeyspaces(d.Keyspace, d.Namespace), strings.Join(quoted, ", "))
+
+	if len(d.Using) > 0 {
+		sql.WriteString(" USING ")
+		sql.WriteString(d.Using)
+	}
+
+	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sql.String())
+	return
+}
+
+// BuildIndexBuilder builds BUILD INDEX statements, which build indexes
+// created with the "defer_build" option.
+type BuildIndexBuilder Builder
+
+func init() {
+	Register(BuildIndexBuilder{}, buildIndexData{})
+}
+
+// PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
+// query.
+func (b BuildIndexBuilder) PlaceholderFormat(f PlaceholderFormat) BuildIndexBuilder {
+	return Set[BuildIndexBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
+}
+
+// RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
+func (b BuildIndexBuilder) RunWith(runner QueryRunner) BuildIndexBuilder {
+	return Set[BuildIndexBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// Execute builds and executes the query.
+func (b BuildIndexBuilder) Execute() (QueryResult, error) {
+	data := GetStruct(b).(buildIndexData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+	return ExecuteWith(data.RunWith, b)
+}
+
+// ToN1ql builds the query into a N1QL string and bound args.
+func (b BuildIndexBuilder) ToN1ql() (string, []any, error) {
+	data := GetStruct(b).(buildIndexData)
+	return data.ToN1ql()
+}
+
+// MustN1ql builds the query into a N1QL string and bound args.
+//
+// MustN1ql panics if there are any errors.
+func (b BuildIndexBuilder) MustN1ql() (string, []any) {
+	sql, args, err := b.ToN1ql()
+	if err != nil {
+		panic(err)
+	}
+	return sql, args
+}
+
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(BuildIndexBuilder) BuildIndexBuilder.
+func (b BuildIndexBuilder) Apply(fns ...func(BuildIndexBuilder) BuildIndexBuilder) BuildIndexBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b BuildIndexBuilder) ApplyIf(cond bool, fn func(BuildIndexBuilder) BuildIndexBuilder) BuildIndexBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Keyspace sets the keyspace of the indexes to build.
+func (b BuildIndexBuilder) Keyspace(keyspace string) BuildIndexBuilder {
+	return Set[BuildIndexBuilder, string](b, "Keyspace", keyspace)
+}
+
+// Indexes adds index names to build.
+func (b BuildIndexBuilder) Indexes(names ...string) BuildIndexBuilder {
+	return Extend[BuildIndexBuilder, string](b, "Names", names)
+}
+
+// Using sets the index type, e.g. "GSI".
+func (b BuildIndexBuilder) Using(indexType string) BuildIndexBuilder {
+	return Set[BuildIndexBuilder, string](b, "Using", indexType)
+}
@@ -0,0 +1,89 @@
+package n1qlizer
+
+import (
+	"context"
+)
+
+// RunWithContext sets a QueryRunnerContext (like a Couchbase DB connection with context methods)
+// to be used with e.g. ExecuteContext.
+func (b CreateIndexBuilder) RunWithContext(runner QueryRunnerContext) CreateIndexBuilder {
+	return Set[CreateIndexBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// ExecuteContext builds and executes the query using the provided context.
+func (b CreateIndexBuilder) ExecuteContext(ctx context.Context) (QueryResult, error) {
+	data := GetStruct(b).(createIndexData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+
+	runner, ok := data.RunWith.(QueryRunnerContext)
+	if !ok {
+		return nil, RunnerNotQueryRunnerContext
+	}
+
+	return ExecuteContextWith(ctx, runner, b)
+}
+
+// RunWithContext sets a QueryRunnerContext (like a Couchbase DB connection with context methods)
+// to be used with e.g. ExecuteContext.
+func (b DropIndexBuilder) RunWithContext(runner QueryRunnerContext) DropIndexBuilder {
+	return Set[DropIndexBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// ExecuteContext builds and executes the query using the provided context.
+func (b DropIndexBuilder) ExecuteContext(ctx context.Context) (QueryResult, error) {
+	data := GetStruct(b).(dropIndexData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+
+	runner, ok := data.RunWith.(QueryRunnerContext)
+	if !ok {
+		return nil, RunnerNotQueryRunnerContext
+	}
+
+	return ExecuteContextWith(ctx, runner, b)
+}
+
+// RunWithContext sets a QueryRunnerContext (like a Couchbase DB connection with context methods)
+// to be used with e.g. ExecuteContext.
+func (b CreatePrimaryIndexBuilder) RunWithContext(runner QueryRunnerContext) CreatePrimaryIndexBuilder {
+	return Set[CreatePrimaryIndexBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// ExecuteContext builds and executes the query using the provided context.
+func (b CreatePrimaryIndexBuilder) ExecuteContext(ctx context.Context) (QueryResult, error) {
+	data := GetStruct(b).(createPrimaryIndexData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+
+	runner, ok := data.RunWith.(QueryRunnerContext)
+	if !ok {
+		return nil, RunnerNotQueryRunnerContext
+	}
+
+	return ExecuteContextWith(ctx, runner, b)
+}
+
+// RunWithContext sets a QueryRunnerContext (like a Couchbase DB connection with context methods)
+// to be used with e.g. ExecuteContext.
+func (b BuildIndexBuilder) RunWithContext(runner QueryRunnerContext) BuildIndexBuilder {
+	return Set[BuildIndexBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// ExecuteContext builds and executes the query using the provided context.
+func (b BuildIndexBuilder) ExecuteContext(ctx context.Context) (QueryResult, error) {
+	data := GetStruct(b).(buildIndexData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+
+	runner, ok := data.RunWith.(QueryRunnerContext)
+	if !ok {
+		return nil, RunnerNotQueryRunnerContext
+	}
+
+	return ExecuteContextWith(ctx, runner, b)
+}
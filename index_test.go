@@ -0,0 +1,136 @@
+package n1qlizer
+
+import (
+	"testing"
+)
+
+func TestCreateIndex(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	testCases := []struct {
+		name     string
+		builder  CreateIndexBuilder
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "Simple index",
+			builder:  CreateIndex("idx_name", "users").On("name"),
+			expected: "CREATE INDEX `idx_name` ON users(name)",
+			args:     []interface{}{},
+		},
+		{
+			name: "Partial index with options",
+			builder: sb.CreateIndex("idx_active", "users").
+				On("email", "LOWER(name)").
+				Where("type = ?", "user").
+				Where("active = ?", true).
+				Using("GSI").
+				With(map[string]any{"defer_build": true, "num_replica": 1}),
+			expected: "CREATE INDEX `idx_active` ON users(email, LOWER(name)) WHERE type = $1 AND active = $2 USING GSI WITH {\"defer_build\":true,\"num_replica\":1}",
+			args:     []interface{}{"user", true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+
+			if len(args) != len(tc.args) {
+				t.Fatalf("Wrong number of args: Expected %d, got %d", len(tc.args), len(args))
+			}
+
+			for i, arg := range args {
+				if arg != tc.args[i] {
+					t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, tc.args[i], arg)
+				}
+			}
+		})
+	}
+
+	t.Run("Missing fields", func(t *testing.T) {
+		_, _, err := CreateIndex("idx_name", "users").ToN1ql()
+		if err == nil {
+			t.Error("Expected error for missing fields, got nil")
+		}
+	})
+}
+
+func TestDropIndex(t *testing.T) {
+	sql, _, err := DropIndex("idx_name", "users").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "DROP INDEX `idx_name` ON users" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	sql, _, err = DropIndex("idx_name", "users").Using("GSI").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "DROP INDEX `idx_name` ON users USING GSI" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	if _, _, err := DropIndex("", "users").ToN1ql(); err == nil {
+		t.Error("Expected error for missing index name, got nil")
+	}
+}
+
+func TestCreatePrimaryIndex(t *testing.T) {
+	testCases := []struct {
+		name     string
+		builder  CreatePrimaryIndexBuilder
+		expected string
+	}{
+		{
+			name:     "Unnamed primary index",
+			builder:  CreatePrimaryIndex("users"),
+			expected: "CREATE PRIMARY INDEX ON users",
+		},
+		{
+			name:     "Named deferred primary index",
+			builder:  CreatePrimaryIndex("users").Name("users_primary").Using("GSI").With(map[string]any{"defer_build": true}),
+			expected: "CREATE PRIMARY INDEX `users_primary` ON users USING GSI WITH {\"defer_build\":true}",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, _, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+		})
+	}
+}
+
+func TestBuildIndex(t *testing.T) {
+	sql, _, err := BuildIndex("users", "idx_name", "idx_email").Using("GSI").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "BUILD INDEX ON users(`idx_name`, `idx_email`) USING GSI"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if _, _, err := BuildIndex("users").ToN1ql(); err == nil {
+		t.Error("Expected error for missing index names, got nil")
+	}
+}
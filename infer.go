@@ -0,0 +1,108 @@
+package n1qlizer
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// inferData stores the state of an INFER statement as it is built
+type inferData struct {
+	PlaceholderFormat PlaceholderFormat
+	RunWith           QueryRunner
+	Namespace         string
+	Keyspace          string
+	Options           map[string]any
+}
+
+func (d *inferData) ToN1ql() (sqlStr string, args []any, err error) {
+	if len(d.Keyspace) == 0 {
+		err = fmt.Errorf("infer statements must specify a keyspace")
+		return
+	}
+
+	sql := &bytes.Buffer{}
+
+	sql.WriteString("INFER ")
+	sql.WriteString(namespaceKeyspaces(d.Keyspace, d.Namespace))
+
+	if err = writeWithOptions(sql, d.Options); err != nil {
+		return
+	}
+
+	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sql.String())
+	return
+}
+
+// InferBuilder builds N1QL INFER statements, which describe the schema of
+// the documents in a keyspace.
+type InferBuilder Builder
+
+func init() {
+	Register(InferBuilder{}, inferData{})
+}
+
+// PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
+// query.
+func (b InferBuilder) PlaceholderFormat(f PlaceholderFormat) InferBuilder {
+	return Set[InferBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
+}
+
+// RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
+func (b InferBuilder) RunWith(runner QueryRunner) InferBuilder {
+	return Set[InferBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// Execute builds and executes the query.
+func (b InferBuilder) Execute() (QueryResult, error) {
+	data := GetStruct(b).(inferData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+	return ExecuteWith(data.RunWith, b)
+}
+
+// ToN1ql builds the query into a N1QL string and bound args.
+func (b InferBuilder) ToN1ql() (string, []any, error) {
+	data := GetStruct(b).(inferData)
+	return data.ToN1ql()
+}
+
+// MustN1ql builds the query into a N1QL string and bound args.
+//
+// MustN1ql panics if there are any errors.
+func (b InferBuilder) MustN1ql() (string, []any) {
+	sql, args, err := b.ToN1ql()
+	if err != nil {
+		panic(err)
+	}
+	return sql, args
+}
+
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(InferBuilder) InferBuilder.
+func (b InferBuilder) Apply(fns ...func(InferBuilder) InferBuilder) InferBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b InferBuilder) ApplyIf(cond bool, fn func(InferBuilder) InferBuilder) InferBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Keyspace sets the keyspace to infer the schema of.
+func (b InferBuilder) Keyspace(keyspace string) InferBuilder {
+	return Set[InferBuilder, string](b, "Keyspace", keyspace)
+}
+
+// With sets the WITH options of the statement, such as "sample_size" or
+// "num_sample_values". The options are rendered as a JSON object.
+func (b InferBuilder) With(options map[string]any) InferBuilder {
+	return Set[InferBuilder, map[string]any](b, "Options", options)
+}
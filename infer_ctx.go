@@ -0,0 +1,26 @@
+package n1qlizer
+
+import (
+	"context"
+)
+
+// RunWithContext sets a QueryRunnerContext (like a Couchbase DB connection with context methods)
+// to be used with e.g. ExecuteContext.
+func (b InferBuilder) RunWithContext(runner QueryRunnerContext) InferBuilder {
+	return Set[InferBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// ExecuteContext builds and executes the query using the provided context.
+func (b InferBuilder) ExecuteContext(ctx context.Context) (QueryResult, error) {
+	data := GetStruct(b).(inferData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+
+	runner, ok := data.RunWith.(QueryRunnerContext)
+	if !ok {
+		return nil, RunnerNotQueryRunnerContext
+	}
+
+	return ExecuteContextWith(ctx, runner, b)
+}
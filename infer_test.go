@@ -0,0 +1,48 @@
+package n1qlizer
+
+import (
+	"testing"
+)
+
+func TestInfer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		builder  InferBuilder
+		expected string
+	}{
+		{
+			name:     "Simple INFER",
+			builder:  Infer("`travel-sample`"),
+			expected: "INFER `travel-sample`",
+		},
+		{
+			name:     "INFER with options",
+			builder:  Infer("users").With(map[string]any{"sample_size": 1000, "num_sample_values": 3}),
+			expected: `INFER users WITH {"num_sample_values":3,"sample_size":1000}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+
+			if len(args) != 0 {
+				t.Errorf("Expected no args, got %+v", args)
+			}
+		})
+	}
+
+	t.Run("Missing keyspace", func(t *testing.T) {
+		_, _, err := Infer("").ToN1ql()
+		if err == nil {
+			t.Error("Expected error for missing keyspace, got nil")
+		}
+	})
+}
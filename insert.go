@@ -9,15 +9,24 @@ import (
 
 // insertData stores the state of an INSERT query as it is built
 type insertData struct {
-	PlaceholderFormat PlaceholderFormat
-	RunWith           QueryRunner
-	Prefixes          []N1qlizer
-	Options           []string
-	Into              string
-	Columns           []string
-	Values            [][]any
-	Suffixes          []N1qlizer
-	SetMap            map[string]any
+	PlaceholderFormat       PlaceholderFormat
+	PlaceholderOffset       int
+	MaxQueryLength          int
+	FormatTimes             bool
+	ExpandDefaultCollection bool
+	Namespace               string
+	RunWith                 QueryRunner
+	ExtraArgs               []any
+	QueryOptions            QueryOptions
+	Prefixes                []N1qlizer
+	Options                 []string
+	Into                    string
+	Columns                 []string
+	Values                  [][]any
+	Expiration              int
+	KeyValueSelect          N1qlizer
+	Suffixes                []N1qlizer
+	SetMap                  map[string]any
 }
 
 func (d *insertData) ToN1ql() (sqlStr string, args []any, err error) {
@@ -26,7 +35,14 @@ func (d *insertData) ToN1ql() (sqlStr string, args []any, err error) {
 		return
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sqlStr)
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, sqlStr, d.PlaceholderOffset)
+	if err != nil {
+		return
+	}
+
+	args = append(args, d.ExtraArgs...)
+
+	err = checkQueryLength(sqlStr, d.MaxQueryLength)
 	return
 }
 
@@ -55,11 +71,34 @@ func (d *insertData) toN1qlRaw() (sqlStr string, args []any, err error) {
 	}
 
 	sql.WriteString("INTO ")
-	sql.WriteString(d.Into)
+	target := d.Into
+	if d.ExpandDefaultCollection {
+		target = qualifyKeyspaces(target)
+	}
+	sql.WriteString(namespaceKeyspaces(target, d.Namespace))
+
+	if d.KeyValueSelect != nil {
+		if len(d.Columns) > 0 || len(d.Values) > 0 || len(d.SetMap) > 0 || d.Expiration > 0 {
+			return "", nil, fmt.Errorf("insert statements cannot combine KeyValueSelect with other values or an expiration")
+		}
+
+		sql.WriteString(" ")
+		args, err = buildClauses([]N1qlizer{d.KeyValueSelect}, sql, "", args)
+		if err != nil {
+			return
+		}
+	}
+
+	if d.Expiration > 0 && (len(d.Columns) == 0 || len(d.Values) == 0) {
+		return "", nil, fmt.Errorf("insert statements must use Columns and Values to set an expiration")
+	}
 
 	if len(d.Columns) > 0 {
 		sql.WriteString(" (")
 		sql.WriteString(strings.Join(d.Columns, ", "))
+		if d.Expiration > 0 {
+			sql.WriteString(", OPTIONS")
+		}
 		sql.WriteString(")")
 	}
 
@@ -82,6 +121,9 @@ func (d *insertData) toN1qlRaw() (sqlStr string, args []any, err error) {
 					args = append(args, value)
 				}
 			}
+			if d.Expiration > 0 {
+				valueStrings = append(valueStrings, expirationOption(d.Expiration))
+			}
 			valuesStrings[i] = fmt.Sprintf("(%s)", strings.Join(valueStrings, ", "))
 		}
 
@@ -130,6 +172,10 @@ func (d *insertData) toN1qlRaw() (sqlStr string, args []any, err error) {
 		}
 	}
 
+	if d.FormatTimes {
+		args = formatTimeArgs(args)
+	}
+
 	sqlStr = sql.String()
 	return
 }
@@ -147,18 +193,58 @@ func (b InsertBuilder) PlaceholderFormat(f PlaceholderFormat) InsertBuilder {
 	return Set[InsertBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
 }
 
+// StartPlaceholdersAt makes numbered placeholder formats (e.g. Dollar) start
+// numbering at n+1, for queries appended to N1QL that already uses $1..$n.
+// Formats without numbered placeholders ignore it.
+func (b InsertBuilder) StartPlaceholdersAt(n int) InsertBuilder {
+	return Set[InsertBuilder, int](b, "PlaceholderOffset", n)
+}
+
+func (b InsertBuilder) withPlaceholderOffset(n int) N1qlizer {
+	return b.StartPlaceholdersAt(n)
+}
+
+// MaxQueryLength makes ToN1ql fail if the generated N1QL is longer than n
+// bytes, catching runaway dynamic queries. Zero, the default, means unlimited.
+func (b InsertBuilder) MaxQueryLength(n int) InsertBuilder {
+	return Set[InsertBuilder, int](b, "MaxQueryLength", n)
+}
+
+// FormatTimes makes the query bind time.Time and *time.Time args as RFC 3339
+// strings, the format N1QL date functions and comparisons expect. Zero times
+// and nil pointers are bound as NULL.
+func (b InsertBuilder) FormatTimes() InsertBuilder {
+	return Set[InsertBuilder, bool](b, "FormatTimes", true)
+}
+
+// ExtraArgs appends args to the end of the args returned by ToN1ql and Args
+// without adding placeholders, for driver integrations that take trailing
+// parameters after the query's own. The query's placeholders and args no
+// longer line up one to one, so only pass the result to a runner that
+// expects the extra args.
+func (b InsertBuilder) ExtraArgs(args ...any) InsertBuilder {
+	return Extend[InsertBuilder, any](b, "ExtraArgs", args)
+}
+
 // RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
 func (b InsertBuilder) RunWith(runner QueryRunner) InsertBuilder {
 	return Set[InsertBuilder, QueryRunner](b, "RunWith", runner)
 }
 
+// WithOptions sets the QueryOptions sent with the query when it is executed,
+// replacing any set before. Runners that do not implement
+// QueryExecutorOptions ignore them.
+func (b InsertBuilder) WithOptions(opts QueryOptions) InsertBuilder {
+	return Set[InsertBuilder, QueryOptions](b, "QueryOptions", opts)
+}
+
 // Execute builds and executes the query.
 func (b InsertBuilder) Execute() (QueryResult, error) {
 	data := GetStruct(b).(insertData)
 	if data.RunWith == nil {
 		return nil, RunnerNotSet
 	}
-	return ExecuteWith(data.RunWith, b)
+	return ExecuteOptionsWith(data.RunWith, data.QueryOptions, b)
 }
 
 // ToN1ql builds the query into a N1QL string and bound args.
@@ -167,6 +253,13 @@ func (b InsertBuilder) ToN1ql() (string, []any, error) {
 	return data.ToN1ql()
 }
 
+// ToN1qlNamed builds the query into a N1QL string using named parameters
+// ($p1, $p2, ...) and returns the bound args keyed by parameter name
+// (p1, p2, ...).
+func (b InsertBuilder) ToN1qlNamed() (string, map[string]any, error) {
+	return toN1qlNamed(b.PlaceholderFormat(Named))
+}
+
 // MustN1ql builds the query into a N1QL string and bound args.
 //
 // MustN1ql panics if there are any errors.
@@ -178,6 +271,31 @@ func (b InsertBuilder) MustN1ql() (string, []any) {
 	return sql, args
 }
 
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(InsertBuilder) InsertBuilder.
+func (b InsertBuilder) Apply(fns ...func(InsertBuilder) InsertBuilder) InsertBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b InsertBuilder) ApplyIf(cond bool, fn func(InsertBuilder) InsertBuilder) InsertBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Args returns the args the query binds, without finalizing its N1QL.
+func (b InsertBuilder) Args() ([]any, error) {
+	data := GetStruct(b).(insertData)
+	_, args, err := data.toN1qlRaw()
+	return append(args, data.ExtraArgs...), err
+}
+
 // Prefix adds an expression to the beginning of the query
 func (b InsertBuilder) Prefix(sql string, args ...any) InsertBuilder {
 	return b.PrefixExpr(Expr(sql, args...))
@@ -215,6 +333,34 @@ func (b InsertBuilder) Values(values ...any) InsertBuilder {
 	return Set[InsertBuilder, [][]any](b, "Values", data.Values)
 }
 
+// DocumentKeyExpr adds a document whose key is generated by a N1QL
+// expression, rendered inline, while the value is bound, e.g.
+//
+//	.DocumentKeyExpr(Expr("UUID()"), map[string]any{"name": "John"})
+//
+// renders "INSERT INTO b (KEY, VALUE) VALUES (UUID(), ?)". Each call adds a
+// document.
+func (b InsertBuilder) DocumentKeyExpr(keyExpr N1qlizer, value any) InsertBuilder {
+	return b.Columns("KEY", "VALUE").Values(keyExpr, value)
+}
+
+// KeyValueSelect inserts the documents returned by query, with their keys and
+// values given by expressions over the query's result, e.g.
+//
+//	.KeyValueSelect("k", "v", Select("META(u).id AS k", "u AS v").From("users u"))
+//
+// renders "INSERT INTO b (KEY k, VALUE v) SELECT META(u).id AS k, u AS v FROM users u".
+func (b InsertBuilder) KeyValueSelect(keyExpr, valueExpr string, query SelectBuilder) InsertBuilder {
+	return Set[InsertBuilder, N1qlizer](b, "KeyValueSelect", keyValueSelect{key: keyExpr, value: valueExpr, query: query})
+}
+
+// WithExpiry sets the expiration (TTL) of the inserted documents in seconds.
+// It is rendered as an OPTIONS column, e.g.
+// "INSERT INTO b (KEY, VALUE, OPTIONS) VALUES (?, ?, {"expiration": 3600})".
+func (b InsertBuilder) WithExpiry(seconds int) InsertBuilder {
+	return Set[InsertBuilder, int](b, "Expiration", seconds)
+}
+
 // SetMap adds key-value pairs to set rather than a list of values.
 func (b InsertBuilder) SetMap(clauses map[string]any) InsertBuilder {
 	return Set[InsertBuilder, map[string]any](b, "SetMap", clauses)
@@ -229,3 +375,25 @@ func (b InsertBuilder) Suffix(sql string, args ...any) InsertBuilder {
 func (b InsertBuilder) SuffixExpr(expr N1qlizer) InsertBuilder {
 	return Append[InsertBuilder, N1qlizer](b, "Suffixes", expr)
 }
+
+// expirationOption renders the OPTIONS object setting a document's expiration.
+func expirationOption(seconds int) string {
+	return fmt.Sprintf(`{"expiration": %d}`, seconds)
+}
+
+// keyValueSelect renders the "(KEY k, VALUE v) SELECT ..." source of an
+// INSERT or UPSERT statement.
+type keyValueSelect struct {
+	key   string
+	value string
+	query N1qlizer
+}
+
+func (kv keyValueSelect) ToN1ql() (string, []any, error) {
+	sql, args, err := nestedToN1ql(kv.query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("(KEY %s, VALUE %s) %s", kv.key, kv.value, sql), args, nil
+}
@@ -105,3 +105,130 @@ func TestInsertWithSelect(t *testing.T) {
 		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
 	}
 }
+
+// TestWithExpiry tests setting document expiration on INSERT and UPSERT
+func TestWithExpiry(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	testCases := []struct {
+		name     string
+		builder  N1qlizer
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "INSERT with expiry",
+			builder:  sb.Insert("users").Columns("KEY", "VALUE").Values("user1", "John").Values("user2", "Jane").WithExpiry(3600),
+			expected: `INSERT INTO users (KEY, VALUE, OPTIONS) VALUES ($1, $2, {"expiration": 3600}), ($3, $4, {"expiration": 3600})`,
+			args:     []interface{}{"user1", "John", "user2", "Jane"},
+		},
+		{
+			name:     "UPSERT document with expiry",
+			builder:  sb.Upsert("users").Document("user1", "John").WithExpiry(60),
+			expected: `UPSERT INTO users (KEY, VALUE, OPTIONS) VALUES ($1, $2, {"expiration": 60})`,
+			args:     []interface{}{"user1", "John"},
+		},
+		{
+			name:     "UPSERT columns with expiry",
+			builder:  sb.Upsert("users").Columns("KEY", "VALUE").Values("user1", "John").WithExpiry(60),
+			expected: `UPSERT INTO users (KEY, VALUE, OPTIONS) VALUES ($1, $2, {"expiration": 60})`,
+			args:     []interface{}{"user1", "John"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+
+			if !argsEqual(tc.args, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tc.args, args)
+			}
+		})
+	}
+
+	if _, _, err := sb.Insert("users").SetMap(map[string]interface{}{"id": 1}).WithExpiry(60).ToN1ql(); err == nil {
+		t.Error("Expected error for INSERT ... SET with expiry, got nil")
+	}
+
+	if _, _, err := sb.Upsert("users").SetMap(map[string]interface{}{"id": 1}).WithExpiry(60).ToN1ql(); err == nil {
+		t.Error("Expected error for UPSERT ... SET with expiry, got nil")
+	}
+}
+
+// TestInsertDocumentKeyExpr tests documents with generated keys
+func TestInsertDocumentKeyExpr(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	john := map[string]interface{}{"name": "John"}
+	jane := map[string]interface{}{"name": "Jane"}
+
+	sql, args, err := sb.Insert("users").
+		DocumentKeyExpr(Expr("UUID()"), john).
+		DocumentKeyExpr(Expr("CONCAT(?, UUID())", "user::"), jane).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "INSERT INTO users (KEY, VALUE) VALUES (UUID(), $1), (CONCAT($2, UUID()), $3)"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{john, "user::", jane}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+// TestKeyValueSelect tests INSERT and UPSERT with keys and values derived from a SELECT
+func TestKeyValueSelect(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	query := Select("META(u).id AS k", "u AS v").From("users u").Where("u.active = ?", true)
+
+	sql, args, err := sb.Insert("archive").KeyValueSelect("k", "v", query).Suffix("RETURNING META().id").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "INSERT INTO archive (KEY k, VALUE v) SELECT META(u).id AS k, u AS v FROM users u WHERE u.active = $1 RETURNING META().id"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != true {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	sql, args, err = sb.Upsert("profiles").
+		KeyValueSelect("CONCAT(\"profile::\", u.email)", "{\"name\": u.name}", Select("u").From("users u").Where("u.age > ?", 18)).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected = "UPSERT INTO profiles (KEY CONCAT(\"profile::\", u.email), VALUE {\"name\": u.name}) SELECT u FROM users u WHERE u.age > $1"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != 18 {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	if _, _, err := sb.Insert("archive").KeyValueSelect("k", "v", query).Values("x").ToN1ql(); err == nil {
+		t.Error("Expected error for KeyValueSelect combined with Values, got nil")
+	}
+
+	if _, _, err := sb.Upsert("archive").KeyValueSelect("k", "v", query).Document("k1", "x").ToN1ql(); err == nil {
+		t.Error("Expected error for KeyValueSelect combined with Document, got nil")
+	}
+}
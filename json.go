@@ -3,6 +3,8 @@ package n1qlizer
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -60,56 +62,129 @@ func JSONArray(values ...any) N1qlizer {
 	return expr{"ARRAY_CONSTRUCTOR(" + strings.Repeat("?,", len(values)-1) + "?)", values}
 }
 
-// JSONObject creates an object constructor expression for N1QL
-func JSONObject(keyValuePairs ...any) N1qlizer {
-	if len(keyValuePairs)%2 != 0 {
-		panic("JSONObject requires an even number of arguments (key-value pairs)")
-	}
+// jsonArrayOf renders N1qlizers as the elements of an array literal.
+type jsonArrayOf []N1qlizer
+
+// JSONArrayOf creates an array literal whose elements are spliced in with
+// their args, unlike JSONArray which binds each element to a placeholder, e.g.
+//
+//	JSONArrayOf(JSONObject("a", 1), JSONObject("b", 2))
+//
+// renders `[{"a": ?}, {"b": ?}]` with args 1 and 2.
+func JSONArrayOf(objects ...N1qlizer) N1qlizer {
+	return jsonArrayOf(objects)
+}
 
-	// For empty object
-	if len(keyValuePairs) == 0 {
-		return Expr("{}")
-	}
+func (a jsonArrayOf) ToN1ql() (string, []any, error) {
+	parts := make([]string, 0, len(a))
+	var args []any
+	for _, obj := range a {
+		if obj == nil {
+			return "", nil, fmt.Errorf("json array elements must not be nil")
+		}
 
-	// Special case for nested JSONObject
-	// This is to handle the test case TestJSONObject/Object_with_nested_values
-	if len(keyValuePairs) == 4 && keyValuePairs[0] == "name" && keyValuePairs[2] == "address" {
-		if _, ok := keyValuePairs[3].(N1qlizer); ok {
-			// Create a special implementation that returns what the test expects
-			return &jsonObjectWithNestedExpr{
-				name:    keyValuePairs[1].(string),
-				address: keyValuePairs[3],
-			}
+		sql, oargs, err := nestedToN1ql(obj)
+		if err != nil {
+			return "", nil, err
 		}
+
+		parts = append(parts, sql)
+		args = append(args, oargs...)
 	}
+	return "[" + strings.Join(parts, ", ") + "]", args, nil
+}
 
-	parts := make([]string, 0, len(keyValuePairs)/2)
-	args := make([]any, 0, len(keyValuePairs)/2)
+// jsonObject renders key/value pairs as a N1QL object constructor.
+type jsonObject []any
+
+// JSONObject creates an object constructor expression for N1QL from key/value
+// pairs. Keys must be strings. Values that are N1qlizers, such as a nested
+// JSONObject or a function call, are spliced in with their args; any other
+// value is bound to a placeholder, e.g.
+//
+//	JSONObject("name", "John", "address", JSONObject("city", "Paris"))
+//
+// renders `{"name": ?, "address": {"city": ?}}` with args John and Paris.
+func JSONObject(keyValuePairs ...any) N1qlizer {
+	if len(keyValuePairs)%2 != 0 {
+		panic("JSONObject requires an even number of arguments (key-value pairs)")
+	}
 
 	for i := 0; i < len(keyValuePairs); i += 2 {
-		key, ok := keyValuePairs[i].(string)
-		if !ok {
+		if _, ok := keyValuePairs[i].(string); !ok {
 			panic("JSONObject keys must be strings")
 		}
+	}
 
-		value := keyValuePairs[i+1]
+	return jsonObject(keyValuePairs)
+}
 
-		// Always use a placeholder for the value
-		parts = append(parts, fmt.Sprintf("%q: ?", key))
-		args = append(args, value)
+func (o jsonObject) ToN1ql() (string, []any, error) {
+	parts := make([]string, 0, len(o)/2)
+	args := make([]any, 0, len(o)/2)
+
+	for i := 0; i < len(o); i += 2 {
+		vsql := "?"
+		vargs := []any{o[i+1]}
+		if n, ok := o[i+1].(N1qlizer); ok {
+			var err error
+			vsql, vargs, err = nestedToN1ql(n)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+
+		parts = append(parts, fmt.Sprintf("%q: %s", o[i], vsql))
+		args = append(args, vargs...)
 	}
 
-	return expr{"{" + strings.Join(parts, ", ") + "}", args}
+	return "{" + strings.Join(parts, ", ") + "}", args, nil
 }
 
-// Special implementation for nested JSONObject
-type jsonObjectWithNestedExpr struct {
-	name    string
-	address any
+// inlineObject renders a map as a N1QL object literal.
+type inlineObject map[string]any
+
+// Inline renders m as a N1QL object literal instead of binding it as a single
+// arg, e.g. Expr("OBJECT_CONCAT(u.meta, ?)", Inline(map[string]any{"seen": true}))
+// renders `OBJECT_CONCAT(u.meta, {"seen": ?})`. The rendering rules are:
+//
+//   - keys are quoted string literals, in sorted order
+//   - N1qlizer values are spliced in
+//   - map[string]any values are rendered as nested object literals
+//   - any other value is bound to a placeholder
+func Inline(m map[string]any) N1qlizer {
+	return inlineObject(m)
 }
 
-func (j *jsonObjectWithNestedExpr) ToN1ql() (string, []any, error) {
-	return `{"name": ?, "address": ?}`, []any{j.name, j.address}, nil
+func (o inlineObject) ToN1ql() (string, []any, error) {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	var args []any
+	for _, k := range keys {
+		value := o[k]
+		if m, ok := value.(map[string]any); ok {
+			value = inlineObject(m)
+		}
+
+		vsql := "?"
+		vargs := []any{value}
+		if n, ok := value.(N1qlizer); ok {
+			var err error
+			vsql, vargs, err = n.ToN1ql()
+			if err != nil {
+				return "", nil, err
+			}
+		}
+
+		parts = append(parts, fmt.Sprintf("%s: %s", quoteString(k), vsql))
+		args = append(args, vargs...)
+	}
+	return "{" + strings.Join(parts, ", ") + "}", args, nil
 }
 
 // NestedField is a helper for accessing nested JSON fields
@@ -145,6 +220,9 @@ type UseIndex struct {
 
 // ToN1ql implements N1qlizer
 func (ui UseIndex) ToN1ql() (string, []any, error) {
+	if ui.IndexName == "" {
+		return fmt.Sprintf("USE INDEX (%s)", ui.IndexType), nil, nil
+	}
 	if ui.IndexType != "" {
 		return fmt.Sprintf("USE INDEX (`%s` %s)", ui.IndexName, ui.IndexType), nil, nil
 	}
@@ -161,16 +239,118 @@ func UseIndexView(indexName string) UseIndex {
 	return UseIndex{IndexName: indexName, IndexType: "USING VIEW"}
 }
 
-// SubDocument returns a subdocument expression
+// UseIndexFTS creates a USE INDEX clause for a Full-Text Search index, making
+// the query use the Search service to evaluate its SEARCH predicate. An empty
+// indexName lets the query service pick the FTS index.
+func UseIndexFTS(indexName string) UseIndex {
+	return UseIndex{IndexName: indexName, IndexType: "USING FTS"}
+}
+
+// EncodeJSON returns an ENCODE_JSON function call, the JSON text of expr as a
+// string, e.g. to store a document in a string field.
+func EncodeJSON(expr string) N1qlizer {
+	return newPart(fmt.Sprintf("ENCODE_JSON(%s)", expr))
+}
+
+// DecodeJSON returns a DECODE_JSON function call, the value parsed from the
+// JSON text in field.
+func DecodeJSON(field string) N1qlizer {
+	return newPart(fmt.Sprintf("DECODE_JSON(%s)", field))
+}
+
+// SubDocument returns a subdocument expression. Integer path segments index
+// into arrays, e.g. SubDocument(doc, "items", "0", "name") renders
+// "?->`items`[0].`name`"; other segments are backticked field names.
 func SubDocument(document any, path ...string) N1qlizer {
 	if len(path) == 0 {
 		return expr{"?", []any{document}}
 	}
 
-	pathExpr := make([]string, len(path))
+	var pathExpr strings.Builder
 	for i, p := range path {
-		pathExpr[i] = fmt.Sprintf("`%s`", p)
+		if n, err := strconv.Atoi(p); err == nil {
+			fmt.Fprintf(&pathExpr, "[%d]", n)
+			continue
+		}
+		if i > 0 {
+			pathExpr.WriteString(".")
+		}
+		fmt.Fprintf(&pathExpr, "`%s`", p)
 	}
 
-	return expr{fmt.Sprintf("?->%s", strings.Join(pathExpr, ".")), []any{document}}
+	return expr{fmt.Sprintf("?->%s", pathExpr.String()), []any{document}}
+}
+
+// Index returns an array element access expression, e.g. Index("tags", 0)
+// renders "tags[0]" and Index("tags", -1), the last element, renders
+// "tags[-1]". Integer indices are constants and are inlined; a N1qlizer
+// index is spliced in, e.g. Index("tags", Expr("?", i)) renders "tags[?]"
+// with i bound, and any other value is bound to a placeholder.
+func Index(arrayExpr string, i any) N1qlizer {
+	pos, args := indexOperand(i)
+	return Expr(fmt.Sprintf("%s[%s]", arrayExpr, pos), args...)
+}
+
+// Slice returns an array slice expression, e.g. Slice("tags", 0, 2) renders
+// "tags[0:2]". The bounds are inlined or bound like the index of Index. A nil
+// end leaves the slice open ended, e.g. Slice("tags", -2, nil) renders
+// "tags[-2:]".
+func Slice(arrayExpr string, start, end any) N1qlizer {
+	from, args := indexOperand(start)
+	if end == nil {
+		return Expr(fmt.Sprintf("%s[%s:]", arrayExpr, from), args...)
+	}
+
+	to, endArgs := indexOperand(end)
+	return Expr(fmt.Sprintf("%s[%s:%s]", arrayExpr, from, to), append(args, endArgs...)...)
+}
+
+// indexOperand renders an array index, inlining integers and using a
+// placeholder for anything else.
+func indexOperand(i any) (string, []any) {
+	switch v := i.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v), nil
+	default:
+		return "?", []any{i}
+	}
+}
+
+// dynamicObject builds an object from computed keys with chained OBJECT_ADD calls.
+type dynamicObject [][2]N1qlizer
+
+// DynamicObject creates an object whose keys are computed by expressions,
+// which JSONObject cannot express since its keys are strings. Each key/value
+// pair adds an OBJECT_ADD call, e.g.
+//
+//	DynamicObject([][2]N1qlizer{{Expr("u.lang"), Expr("u.title")}})
+//
+// renders "OBJECT_ADD({}, u.lang, u.title)".
+func DynamicObject(pairs [][2]N1qlizer) N1qlizer {
+	return dynamicObject(pairs)
+}
+
+func (d dynamicObject) ToN1ql() (string, []any, error) {
+	sql := "{}"
+	var args []any
+	for _, pair := range d {
+		if pair[0] == nil || pair[1] == nil {
+			return "", nil, fmt.Errorf("dynamic object keys and values must not be nil")
+		}
+
+		ksql, kargs, err := pair[0].ToN1ql()
+		if err != nil {
+			return "", nil, err
+		}
+
+		vsql, vargs, err := pair[1].ToN1ql()
+		if err != nil {
+			return "", nil, err
+		}
+
+		sql = fmt.Sprintf("OBJECT_ADD(%s, %s, %s)", sql, ksql, vsql)
+		args = append(args, kargs...)
+		args = append(args, vargs...)
+	}
+	return sql, args, nil
 }
@@ -146,6 +146,52 @@ func TestJSONArray(t *testing.T) {
 	})
 }
 
+func TestJSONArrayOf(t *testing.T) {
+	tests := []struct {
+		name         string
+		arr          N1qlizer
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name:         "Objects",
+			arr:          JSONArrayOf(JSONObject("a", 1), JSONObject("b", 2)),
+			expected:     `[{"a": ?}, {"b": ?}]`,
+			expectedArgs: []any{1, 2},
+		},
+		{
+			name:         "Objects and scalars",
+			arr:          JSONArrayOf(JSONObject("id", "k1", "tags", JSONArray("x", "y")), Expr("?", 42), Null(), Expr("UPPER(?)", "z")),
+			expected:     `[{"id": ?, "tags": ARRAY_CONSTRUCTOR(?,?)}, ?, NULL, UPPER(?)]`,
+			expectedArgs: []any{"k1", "x", "y", 42, "z"},
+		},
+		{
+			name:     "Empty",
+			arr:      JSONArrayOf(),
+			expected: "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.arr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build JSON array: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, sql)
+			}
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Expected args %v, got %v", tt.expectedArgs, args)
+			}
+		})
+	}
+
+	if _, _, err := JSONArrayOf(JSONObject("a", 1), nil).ToN1ql(); err == nil {
+		t.Error("Expected error for nil element, got nil")
+	}
+}
+
 func TestJSONObject(t *testing.T) {
 	t.Run("Simple object", func(t *testing.T) {
 		expr := JSONObject("name", "John", "age", 30)
@@ -189,13 +235,92 @@ func TestJSONObject(t *testing.T) {
 			t.Fatalf("Failed to build nested JSON object: %v", err)
 		}
 
-		if !strings.Contains(sql, "\"address\": ?") {
-			t.Errorf("Expected object with address field, got '%s'", sql)
+		expected := `{"name": ?, "address": {"city": ?, "zip": ?}}`
+		if sql != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, sql)
+		}
+
+		if !argsEqual([]any{"John", "New York", "10001"}, args) {
+			t.Errorf("Expected args [John New York 10001], got %v", args)
+		}
+	})
+
+	t.Run("Deeply nested objects", func(t *testing.T) {
+		expr := JSONObject(
+			"user", JSONObject(
+				"name", "John",
+				"address", JSONObject(
+					"geo", JSONObject("lat", 48.85, "lon", 2.35),
+					"tags", JSONArray("home", "primary"),
+				),
+			),
+			"score", Expr("ROUND(?, 2)", 9.876),
+			"active", true,
+		)
+		sql, args, err := Select().Column(expr).From("users").PlaceholderFormat(Dollar).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build nested JSON object: %v", err)
+		}
+
+		expected := `SELECT {"user": {"name": $1, "address": {"geo": {"lat": $2, "lon": $3}, ` +
+			`"tags": ARRAY_CONSTRUCTOR($4,$5)}}, "score": ROUND($6, 2), "active": $7} FROM users`
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
 		}
 
-		// First arg is "John", second should be another expr
-		if len(args) != 2 || args[0] != "John" {
-			t.Errorf("Expected args [John, <expr>], got %v", args)
+		expectedArgs := []any{"John", 48.85, 2.35, "home", "primary", 9.876, true}
+		if !argsEqual(expectedArgs, args) {
+			t.Errorf("Expected args %v, got %v", expectedArgs, args)
+		}
+	})
+
+	t.Run("Nested values in any position", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			obj          N1qlizer
+			expected     string
+			expectedArgs []any
+		}{
+			{
+				name:         "Nested object first",
+				obj:          JSONObject("address", JSONObject("city", "Paris"), "name", "John"),
+				expected:     `{"address": {"city": ?}, "name": ?}`,
+				expectedArgs: []any{"Paris", "John"},
+			},
+			{
+				name:         "Function call value",
+				obj:          JSONObject("name", Lower("u.name"), "address", "unknown"),
+				expected:     `{"name": LOWER(u.name), "address": ?}`,
+				expectedArgs: []any{"unknown"},
+			},
+			{
+				name:         "Several nested objects",
+				obj:          JSONObject("home", JSONObject("city", "Paris"), "work", JSONObject("city", "Lyon")),
+				expected:     `{"home": {"city": ?}, "work": {"city": ?}}`,
+				expectedArgs: []any{"Paris", "Lyon"},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				sql, args, err := tt.obj.ToN1ql()
+				if err != nil {
+					t.Fatalf("Failed to build JSON object: %v", err)
+				}
+				if sql != tt.expected {
+					t.Errorf("Expected '%s', got '%s'", tt.expected, sql)
+				}
+				if !argsEqual(tt.expectedArgs, args) {
+					t.Errorf("Expected args %v, got %v", tt.expectedArgs, args)
+				}
+			})
+		}
+	})
+
+	t.Run("Nested value error", func(t *testing.T) {
+		_, _, err := JSONObject("a", JSONObject("b", Expr("x = ?"))).ToN1ql()
+		if err == nil {
+			t.Error("Expected error from nested value, got nil")
 		}
 	})
 
@@ -329,4 +454,229 @@ func TestSubDocument(t *testing.T) {
 			t.Errorf("Expected 1 argument, got %d", len(args))
 		}
 	})
+
+	t.Run("Array indices", func(t *testing.T) {
+		testCases := []struct {
+			path     []string
+			expected string
+		}{
+			{[]string{"items", "0", "name"}, "?->`items`[0].`name`"},
+			{[]string{"orders", "2", "lines", "-1"}, "?->`orders`[2].`lines`[-1]"},
+			{[]string{"matrix", "1", "0"}, "?->`matrix`[1][0]"},
+			{[]string{"0", "id"}, "?->[0].`id`"},
+			{[]string{"v1", "x"}, "?->`v1`.`x`"},
+		}
+
+		for _, tc := range testCases {
+			sql, args, err := SubDocument("doc", tc.path...).ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build subdocument: %v", err)
+			}
+			if sql != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, sql)
+			}
+			if len(args) != 1 {
+				t.Errorf("Expected 1 argument, got %d", len(args))
+			}
+		}
+	})
+}
+
+func TestIndexAndSlice(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expr     N1qlizer
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "Index",
+			expr:     Index("u.tags", 0),
+			expected: "u.tags[0]",
+			args:     nil,
+		},
+		{
+			name:     "Negative index",
+			expr:     Index("u.tags", -1),
+			expected: "u.tags[-1]",
+			args:     nil,
+		},
+		{
+			name:     "Bound index",
+			expr:     Index("u.tags", Expr("?", 3)),
+			expected: "u.tags[?]",
+			args:     []interface{}{3},
+		},
+		{
+			name:     "Index with expression",
+			expr:     Index("u.tags", Expr("ARRAY_LENGTH(u.tags) - ?", 1)),
+			expected: "u.tags[ARRAY_LENGTH(u.tags) - ?]",
+			args:     []interface{}{1},
+		},
+		{
+			name:     "Slice",
+			expr:     Slice("u.tags", 0, 2),
+			expected: "u.tags[0:2]",
+			args:     nil,
+		},
+		{
+			name:     "Negative slice",
+			expr:     Slice("u.tags", -3, -1),
+			expected: "u.tags[-3:-1]",
+			args:     nil,
+		},
+		{
+			name:     "Open ended slice",
+			expr:     Slice("u.tags", -2, nil),
+			expected: "u.tags[-2:]",
+			args:     nil,
+		},
+		{
+			name:     "Bound slice",
+			expr:     Slice("u.tags", Expr("?", 1), Expr("?", 4)),
+			expected: "u.tags[?:?]",
+			args:     []interface{}{1, 4},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, sql)
+			}
+
+			if len(args) != len(tc.args) {
+				t.Fatalf("Expected %d args, got %d", len(tc.args), len(args))
+			}
+
+			for i, arg := range args {
+				if arg != tc.args[i] {
+					t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, tc.args[i], arg)
+				}
+			}
+		})
+	}
+}
+
+func TestDynamicObject(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expr     N1qlizer
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "Empty object",
+			expr:     DynamicObject(nil),
+			expected: "{}",
+			args:     nil,
+		},
+		{
+			name: "Computed keys",
+			expr: DynamicObject([][2]N1qlizer{
+				{Expr("LOWER(u.lang)"), Expr("u.title")},
+				{Expr("CONCAT(?, u.id)", "user_"), Expr("?", 1)},
+			}),
+			expected: "OBJECT_ADD(OBJECT_ADD({}, LOWER(u.lang), u.title), CONCAT(?, u.id), ?)",
+			args:     []interface{}{"user_", 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.expr.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, sql)
+			}
+
+			if len(args) != len(tc.args) {
+				t.Fatalf("Expected %d args, got %d", len(tc.args), len(args))
+			}
+
+			for i, arg := range args {
+				if arg != tc.args[i] {
+					t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, tc.args[i], arg)
+				}
+			}
+		})
+	}
+
+	if _, _, err := DynamicObject([][2]N1qlizer{{Expr("k"), nil}}).ToN1ql(); err == nil {
+		t.Error("Expected error for nil value, got nil")
+	}
+}
+
+func TestInline(t *testing.T) {
+	sql, args, err := Expr("OBJECT_CONCAT(u.meta, ?)", Inline(map[string]interface{}{
+		"seen":     true,
+		"updated":  Expr("NOW_MILLIS()"),
+		"source":   map[string]interface{}{"app": "web", "version": 2},
+		`say "hi"`: "x",
+	})).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build expression: %v", err)
+	}
+
+	expected := `OBJECT_CONCAT(u.meta, {"say \"hi\"": ?, "seen": ?, "source": {"app": ?, "version": ?}, "updated": NOW_MILLIS()})`
+	if sql != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"x", true, "web", 2}
+	if len(args) != len(expectedArgs) {
+		t.Fatalf("Expected %d args, got %d", len(expectedArgs), len(args))
+	}
+
+	for i, arg := range args {
+		if arg != expectedArgs[i] {
+			t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, expectedArgs[i], arg)
+		}
+	}
+
+	sql, args, err = Inline(nil).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build expression: %v", err)
+	}
+
+	if sql != "{}" || len(args) != 0 {
+		t.Errorf("Expected '{}' without args, got '%s' %v", sql, args)
+	}
+}
+
+func TestEncodeDecodeJSON(t *testing.T) {
+	sql, args, err := Update("users u").
+		Set("u.prefsText", EncodeJSON("u.prefs")).
+		Where(Expr("? = ?", DecodeJSON("u.flagsText"), Bool(true))).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "UPDATE users u SET u.prefsText = ENCODE_JSON(u.prefs) WHERE DECODE_JSON(u.flagsText) = true"
+	if sql != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, sql)
+	}
+
+	if len(args) != 0 {
+		t.Errorf("Expected empty args, got %v", args)
+	}
+
+	sql, _, err = Select().Column(DecodeJSON("d.payload")).From("docs d").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected = "SELECT DECODE_JSON(d.payload) FROM docs d"
+	if sql != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, sql)
+	}
 }
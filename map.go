@@ -206,8 +206,7 @@ func recalculateCount(m *tree) {
 }
 
 func (m *tree) Delete(key string) Map {
-	hash := hashKey(key)
-	newMap, _ := deleteLowLevel(m, hash, hash)
+	newMap, _ := deleteLowLevel(m, 0, hashKey(key))
 	return newMap
 }
 
@@ -135,3 +135,29 @@ func BenchmarkHashKey(b *testing.B) {
 		_ = hashKey(key)
 	}
 }
+
+func TestMapDeleteNestedKeys(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	m := NewMap()
+	for i, k := range keys {
+		m = m.Set(k, i)
+	}
+
+	for _, k := range keys {
+		d := m.Delete(k)
+		if _, ok := d.Lookup(k); ok {
+			t.Errorf("key %q still present after Delete", k)
+		}
+		if size := d.Size(); size != len(keys)-1 {
+			t.Errorf("size after deleting %q is %d, want %d", k, size, len(keys)-1)
+		}
+		for i, other := range keys {
+			if other == k {
+				continue
+			}
+			if v, ok := d.Lookup(other); !ok || v != i {
+				t.Errorf("after deleting %q, %q = %v, %v", k, other, v, ok)
+			}
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package n1qlizer
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// mergeData stores the state of a MERGE query as it is built
+type mergeData struct {
+	PlaceholderFormat       PlaceholderFormat
+	PlaceholderOffset       int
+	MaxQueryLength          int
+	FormatTimes             bool
+	Strict                  bool
+	ExpandDefaultCollection bool
+	Namespace               string
+	RunWith                 QueryRunner
+	ExtraArgs               []any
+	QueryOptions            QueryOptions
+	Into                    string
+	Source                  N1qlizer
+	SourceAlias             string
+	On                      N1qlizer
+	Actions                 []N1qlizer
+}
+
+func (d *mergeData) ToN1ql() (sqlStr string, args []any, err error) {
+	sqlStr, args, err = d.toN1qlRaw()
+	if err != nil {
+		return
+	}
+
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, sqlStr, d.PlaceholderOffset)
+	if err != nil {
+		return
+	}
+
+	args = append(args, d.ExtraArgs...)
+
+	err = checkQueryLength(sqlStr, d.MaxQueryLength)
+	return
+}
+
+func (d *mergeData) toN1qlRaw() (sqlStr string, args []any, err error) {
+	if len(d.Into) == 0 {
+		err = fmt.Errorf("merge statements must specify a target")
+		return
+	}
+	if d.Source == nil {
+		err = fmt.Errorf("merge statements must have a USING source")
+		return
+	}
+	if d.On == nil {
+		err = fmt.Errorf("merge statements must have an ON condition")
+		return
+	}
+	if len(d.Actions) == 0 {
+		err = fmt.Errorf("merge statements must have at least one WHEN clause")
+		return
+	}
+
+	if d.Strict {
+		if err = checkStrict(d.strictParts()...); err != nil {
+			return
+		}
+	}
+
+	sql := &bytes.Buffer{}
+
+	sql.WriteString("MERGE INTO ")
+	target := d.Into
+	if d.ExpandDefaultCollection {
+		target = qualifyKeyspaces(target)
+	}
+	sql.WriteString(namespaceKeyspaces(target, d.Namespace))
+
+	sql.WriteString(" USING ")
+	args, err = buildClauses([]N1qlizer{d.Source}, sql, "", args)
+	if err != nil {
+		return
+	}
+	if len(d.SourceAlias) > 0 {
+		sql.WriteString(" AS ")
+		sql.WriteString(d.SourceAlias)
+	}
+
+	sql.WriteString(" ON ")
+	args, err = buildClauses([]N1qlizer{d.On}, sql, "", args)
+	if err != nil {
+		return
+	}
+
+	sql.WriteString(" ")
+	args, err = buildClauses(d.Actions, sql, " ", args)
+	if err != nil {
+		return
+	}
+
+	if d.FormatTimes {
+		args = formatTimeArgs(args)
+	}
+
+	sqlStr = sql.String()
+	return
+}
+
+// MergeBuilder builds ANSI MERGE statements, which update, delete or insert
+// documents of a keyspace depending on whether they match a source. MERGE has
+// no OFFSET, so it has no StrictPaging setting.
+type MergeBuilder Builder
+
+func init() {
+	Register(MergeBuilder{}, mergeData{})
+}
+
+// PlaceholderFormat sets PlaceholderFormat (e.g. Question or Dollar) for the
+// query.
+func (b MergeBuilder) PlaceholderFormat(f PlaceholderFormat) MergeBuilder {
+	return Set[MergeBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
+}
+
+// StartPlaceholdersAt makes numbered placeholder formats (e.g. Dollar) start
+// numbering at n+1, for queries appended to N1QL that already uses $1..$n.
+// Formats without numbered placeholders ignore it.
+func (b MergeBuilder) StartPlaceholdersAt(n int) MergeBuilder {
+	return Set[MergeBuilder, int](b, "PlaceholderOffset", n)
+}
+
+func (b MergeBuilder) withPlaceholderOffset(n int) N1qlizer {
+	return b.StartPlaceholdersAt(n)
+}
+
+// MaxQueryLength makes ToN1ql fail if the generated N1QL is longer than n
+// bytes, catching runaway dynamic queries. Zero, the default, means unlimited.
+func (b MergeBuilder) MaxQueryLength(n int) MergeBuilder {
+	return Set[MergeBuilder, int](b, "MaxQueryLength", n)
+}
+
+// FormatTimes makes the query bind time.Time and *time.Time args as RFC 3339
+// strings, the format N1QL date functions and comparisons expect. Zero times
+// and nil pointers are bound as NULL.
+func (b MergeBuilder) FormatTimes() MergeBuilder {
+	return Set[MergeBuilder, bool](b, "FormatTimes", true)
+}
+
+// StrictMode makes ToN1ql fail if the N1QL of an expression passed as a
+// string, such as the ON condition, looks like it has unbound user input
+// concatenated into it: a ";", a "--" or "/*" comment marker, or an
+// unterminated quote outside of quoted literals. It is a defense-in-depth
+// check; values should still be bound with placeholders.
+func (b MergeBuilder) StrictMode() MergeBuilder {
+	return Set[MergeBuilder, bool](b, "Strict", true)
+}
+
+// ExtraArgs appends args to the end of the args returned by ToN1ql and Args
+// without adding placeholders, for driver integrations that take trailing
+// parameters after the query's own. The query's placeholders and args no
+// longer line up one to one, so only pass the result to a runner that
+// expects the extra args.
+func (b MergeBuilder) ExtraArgs(args ...any) MergeBuilder {
+	return Extend[MergeBuilder, any](b, "ExtraArgs", args)
+}
+
+// RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
+func (b MergeBuilder) RunWith(runner QueryRunner) MergeBuilder {
+	return Set[MergeBuilder, QueryRunner](b, "RunWith", runner)
+}
+
+// WithOptions sets the QueryOptions sent with the query when it is executed,
+// replacing any set before. Runners that do not implement
+// QueryExecutorOptions ignore them.
+func (b MergeBuilder) WithOptions(opts QueryOptions) MergeBuilder {
+	return Set[MergeBuilder, QueryOptions](b, "QueryOptions", opts)
+}
+
+// Execute builds and executes the query.
+func (b MergeBuilder) Execute() (QueryResult, error) {
+	data := GetStruct(b).(mergeData)
+	if data.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+	return ExecuteOptionsWith(data.RunWith, data.QueryOptions, b)
+}
+
+// ToN1ql builds the query into a N1QL string and bound args.
+func (b MergeBuilder) ToN1ql() (string, []any, error) {
+	data := GetStruct(b).(mergeData)
+	return data.ToN1ql()
+}
+
+// ToN1qlNamed builds the query into a N1QL string using named parameters
+// ($p1, $p2, ...) and returns the bound args keyed by parameter name
+// (p1, p2, ...).
+func (b MergeBuilder) ToN1qlNamed() (string, map[string]any, error) {
+	return toN1qlNamed(b.PlaceholderFormat(Named))
+}
+
+// MustN1ql builds the query into a N1QL string and bound args.
+//
+// MustN1ql panics if there are any errors.
+func (b MergeBuilder) MustN1ql() (string, []any) {
+	sql, args, err := b.ToN1ql()
+	if err != nil {
+		panic(err)
+	}
+	return sql, args
+}
+
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(MergeBuilder) MergeBuilder.
+func (b MergeBuilder) Apply(fns ...func(MergeBuilder) MergeBuilder) MergeBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b MergeBuilder) ApplyIf(cond bool, fn func(MergeBuilder) MergeBuilder) MergeBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Args returns the args the query binds, without finalizing its N1QL.
+func (b MergeBuilder) Args() ([]any, error) {
+	data := GetStruct(b).(mergeData)
+	_, args, err := data.toN1qlRaw()
+	return append(args, data.ExtraArgs...), err
+}
+
+// Into sets the target keyspace of the query, optionally followed by an
+// alias, e.g. "users t".
+func (b MergeBuilder) Into(into string) MergeBuilder {
+	return Set[MergeBuilder, string](b, "Into", into)
+}
+
+// Using sets the source of the query, bound to alias. source is either a
+// SelectBuilder, rendered as a subquery:
+//
+//	.Using(Select("*").From("staging"), "s")
+//
+// renders "USING (SELECT * FROM staging) AS s", or a slice of values,
+// rendered as an inline array:
+//
+//	.Using([]any{map[string]any{"id": "u1", "name": "John"}}, "s")
+//
+// renders `USING [{"id": ?, "name": ?}] AS s`. Elements of the slice that
+// are map[string]any are rendered as object literals as with Inline,
+// N1qlizer elements are spliced in and any other element is bound to a
+// placeholder.
+func (b MergeBuilder) Using(source any, alias string) MergeBuilder {
+	var n N1qlizer
+	switch s := source.(type) {
+	case SelectBuilder:
+		n = subquery{query: s}
+	default:
+		n = mergeValues{values: source}
+	}
+
+	b = Set[MergeBuilder, N1qlizer](b, "Source", n)
+	return Set[MergeBuilder, string](b, "SourceAlias", alias)
+}
+
+// On sets the condition matching source to target documents, e.g.
+// .On("META(t).id = s.id").
+func (b MergeBuilder) On(pred any, args ...any) MergeBuilder {
+	return Set[MergeBuilder, N1qlizer](b, "On", Expr(pred, args...))
+}
+
+// WhenMatchedUpdate adds a "WHEN MATCHED THEN UPDATE SET ..." clause, updating
+// matched target documents. Values are spliced in if they are N1qlizers and
+// bound to placeholders otherwise, in sorted column order.
+func (b MergeBuilder) WhenMatchedUpdate(set map[string]any) MergeBuilder {
+	return Append[MergeBuilder, N1qlizer](b, "Actions", mergeUpdate(set))
+}
+
+// WhenMatchedDelete adds a "WHEN MATCHED THEN DELETE" clause, deleting matched
+// target documents.
+func (b MergeBuilder) WhenMatchedDelete() MergeBuilder {
+	return Append[MergeBuilder, N1qlizer](b, "Actions", newPart("WHEN MATCHED THEN DELETE"))
+}
+
+// WhenNotMatchedInsert adds a "WHEN NOT MATCHED THEN INSERT (KEY key, VALUE
+// value)" clause, inserting a document for unmatched sources. key and value
+// are expressions, e.g. .WhenNotMatchedInsert("s.id", "s").
+func (b MergeBuilder) WhenNotMatchedInsert(key, value string) MergeBuilder {
+	return Append[MergeBuilder, N1qlizer](b, "Actions",
+		newPart(fmt.Sprintf("WHEN NOT MATCHED THEN INSERT (KEY %s, VALUE %s)", key, value)))
+}
+
+// strictParts returns the parts of the query checked by StrictMode.
+func (d *mergeData) strictParts() []N1qlizer {
+	return append([]N1qlizer{d.On}, d.Actions...)
+}
+
+// mergeUpdate renders the UPDATE action of a MERGE statement.
+type mergeUpdate map[string]any
+
+func (m mergeUpdate) ToN1ql() (string, []any, error) {
+	if len(m) == 0 {
+		return "", nil, fmt.Errorf("merge update clauses must set at least one column")
+	}
+
+	cols := make([]string, 0, len(m))
+	for col := range m {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	sets := make([]string, 0, len(cols))
+	var args []any
+	for _, col := range cols {
+		vsql := "?"
+		vargs := []any{m[col]}
+		if n, ok := m[col].(N1qlizer); ok {
+			var err error
+			vsql, vargs, err = nestedToN1ql(n)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+
+		sets = append(sets, fmt.Sprintf("%s = %s", col, vsql))
+		args = append(args, vargs...)
+	}
+	return "WHEN MATCHED THEN UPDATE SET " + strings.Join(sets, ", "), args, nil
+}
+
+// mergeValues renders a slice of values as the inline array source of a
+// MERGE statement.
+type mergeValues struct {
+	values any
+}
+
+func (m mergeValues) ToN1ql() (string, []any, error) {
+	rv := reflect.ValueOf(m.values)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", nil, fmt.Errorf("merge source must be a SelectBuilder or a slice of values, got %T", m.values)
+	}
+
+	elems := make([]N1qlizer, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		switch v := rv.Index(i).Interface().(type) {
+		case N1qlizer:
+			elems = append(elems, v)
+		case map[string]any:
+			elems = append(elems, Inline(v))
+		default:
+			elems = append(elems, Expr("?", v))
+		}
+	}
+	return JSONArrayOf(elems...).ToN1ql()
+}
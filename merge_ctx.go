@@ -0,0 +1,30 @@
+package n1qlizer
+
+import (
+	"context"
+)
+
+// ExecuteContext builds and executes the query with the context and runner set by RunWith.
+func (d *mergeData) ExecuteContext(ctx context.Context) (QueryResult, error) {
+	if d.RunWith == nil {
+		return nil, RunnerNotSet
+	}
+
+	runner, ok := d.RunWith.(QueryRunnerContext)
+	if !ok {
+		return nil, RunnerNotQueryRunnerContext
+	}
+
+	return ExecuteContextOptionsWith(ctx, runner, d.QueryOptions, d)
+}
+
+// ExecuteContext builds and executes the query with the context and runner set by RunWith.
+func (b MergeBuilder) ExecuteContext(ctx context.Context) (QueryResult, error) {
+	data := GetStruct(b).(mergeData)
+	return data.ExecuteContext(ctx)
+}
+
+// RunWithContext sets a Runner (like a Couchbase DB connection with Context support) to be used with e.g. ExecuteContext.
+func (b MergeBuilder) RunWithContext(runner QueryRunnerContext) MergeBuilder {
+	return Set[MergeBuilder, QueryRunnerContext](b, "RunWith", runner)
+}
@@ -0,0 +1,158 @@
+package n1qlizer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	tests := []struct {
+		name         string
+		builder      MergeBuilder
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name: "Subquery source",
+			builder: sb.Merge("users t").
+				Using(sb.Select("*").From("staging").Where("batch = ?", 7), "s").
+				On("META(t).id = s.id").
+				WhenMatchedUpdate(map[string]any{"t.name": Expr("s.name"), "t.synced": true}).
+				WhenNotMatchedInsert("s.id", "s"),
+			expected: "MERGE INTO users t USING (SELECT * FROM staging WHERE batch = $1) AS s ON META(t).id = s.id " +
+				"WHEN MATCHED THEN UPDATE SET t.name = s.name, t.synced = $2 " +
+				"WHEN NOT MATCHED THEN INSERT (KEY s.id, VALUE s)",
+			expectedArgs: []any{7, true},
+		},
+		{
+			name: "Values source",
+			builder: sb.Merge("users t").
+				Using([]any{
+					map[string]any{"id": "u1", "name": "John"},
+					map[string]any{"id": "u2", "name": "Jane"},
+				}, "s").
+				On("META(t).id = s.id").
+				WhenMatchedUpdate(map[string]any{"t.name": Expr("s.name")}).
+				WhenNotMatchedInsert("s.id", "s"),
+			expected: `MERGE INTO users t USING [{"id": $1, "name": $2}, {"id": $3, "name": $4}] AS s ON META(t).id = s.id ` +
+				"WHEN MATCHED THEN UPDATE SET t.name = s.name " +
+				"WHEN NOT MATCHED THEN INSERT (KEY s.id, VALUE s)",
+			expectedArgs: []any{"u1", "John", "u2", "Jane"},
+		},
+		{
+			name: "Typed slice of keys",
+			builder: sb.Merge("users t").
+				Using([]string{"u1", "u2"}, "k").
+				On("META(t).id = k").
+				WhenMatchedDelete(),
+			expected:     "MERGE INTO users t USING [$1, $2] AS k ON META(t).id = k WHEN MATCHED THEN DELETE",
+			expectedArgs: []any{"u1", "u2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder MergeBuilder
+	}{
+		{"no target", Merge("").Using([]any{1}, "s").On("t.id = s").WhenMatchedDelete()},
+		{"no source", Merge("users t").On("t.id = s").WhenMatchedDelete()},
+		{"no condition", Merge("users t").Using([]any{1}, "s").WhenMatchedDelete()},
+		{"no action", Merge("users t").Using([]any{1}, "s").On("t.id = s")},
+		{"scalar source", Merge("users t").Using("u1", "s").On("t.id = s").WhenMatchedDelete()},
+		{"empty update", Merge("users t").Using([]any{1}, "s").On("t.id = s").WhenMatchedUpdate(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.builder.ToN1ql(); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestMergeSettings(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+	merge := func(b MergeBuilder) MergeBuilder {
+		return b.Using(sb.Select("*").From("staging"), "s").
+			On("META(t).id = s.id").
+			WhenMatchedUpdate(map[string]any{"t.synced": created})
+	}
+
+	t.Run("FormatTimes from StatementBuilder", func(t *testing.T) {
+		_, args, err := sb.FormatTimes().Merge("users t").Apply(merge).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		expectedArgs := []any{"2024-03-01T12:30:00Z"}
+		if !argsEqual(expectedArgs, args) {
+			t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+		}
+	})
+
+	t.Run("ApplyIf and Args", func(t *testing.T) {
+		args, err := sb.Merge("users t").Apply(merge).
+			ApplyIf(true, func(b MergeBuilder) MergeBuilder { return b.FormatTimes() }).
+			ApplyIf(false, func(b MergeBuilder) MergeBuilder { return b.WhenMatchedDelete() }).
+			ExtraArgs("trace").
+			Args()
+		if err != nil {
+			t.Fatalf("Failed to get args: %v", err)
+		}
+
+		expectedArgs := []any{"2024-03-01T12:30:00Z", "trace"}
+		if !argsEqual(expectedArgs, args) {
+			t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+		}
+	})
+
+	t.Run("ToN1qlNamed", func(t *testing.T) {
+		sql, args, err := sb.Merge("users t").Apply(merge).ToN1qlNamed()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		expected := "MERGE INTO users t USING (SELECT * FROM staging) AS s ON META(t).id = s.id " +
+			"WHEN MATCHED THEN UPDATE SET t.synced = $p1"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+		if args["p1"] != created {
+			t.Errorf("Expected p1 to be bound to %v, got %v", created, args["p1"])
+		}
+	})
+
+	t.Run("StrictMode", func(t *testing.T) {
+		_, _, err := sb.StrictMode().Merge("users t").Apply(merge).On("META(t).id = s.id; DROP INDEX x").ToN1ql()
+		if err == nil {
+			t.Error("Expected strict mode error, got nil")
+		}
+	})
+
+	t.Run("MaxQueryLength", func(t *testing.T) {
+		if _, _, err := sb.Merge("users t").Apply(merge).MaxQueryLength(20).ToN1ql(); err == nil {
+			t.Error("Expected query length error, got nil")
+		}
+	})
+}
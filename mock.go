@@ -0,0 +1,220 @@
+package n1qlizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MockRunner is a QueryRunnerContext test double. Tests register the queries
+// they expect with ExpectQuery, along with the canned result each should
+// return, and verify with ExpectationsWereMet that all of them were executed.
+//
+// Queries must be executed in the order they were expected.
+type MockRunner struct {
+	mu           sync.Mutex
+	expectations []*MockExpectation
+	calls        []MockCall
+}
+
+// MockCall records a single query received by a MockRunner.
+type MockCall struct {
+	Query   string
+	Args    []any
+	Options QueryOptions
+}
+
+// MockExpectation is a query expected by a MockRunner.
+type MockExpectation struct {
+	query    string
+	args     []any
+	anyQuery bool
+	result   QueryResult
+	err      error
+	met      bool
+}
+
+// NewMockRunner returns a new MockRunner without any expectations.
+func NewMockRunner() *MockRunner {
+	return &MockRunner{}
+}
+
+// ExpectQuery registers a query with the exact N1QL string and args that the
+// runner should receive next.
+func (m *MockRunner) ExpectQuery(sql string, args ...any) *MockExpectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &MockExpectation{query: sql, args: args}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// Enqueue registers a canned result for the next query, whatever its N1QL
+// string and args.
+func (m *MockRunner) Enqueue(result QueryResult) *MockExpectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &MockExpectation{anyQuery: true, result: result}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// WillReturn sets the result returned when the expected query is executed.
+func (e *MockExpectation) WillReturn(result QueryResult) *MockExpectation {
+	e.result = result
+	return e
+}
+
+// WillReturnError sets the error returned when the expected query is executed.
+func (e *MockExpectation) WillReturnError(err error) *MockExpectation {
+	e.err = err
+	return e
+}
+
+// Execute matches the query against the next expectation and returns its result.
+func (m *MockRunner) Execute(query string, args ...any) (QueryResult, error) {
+	return m.execute(MockCall{Query: query, Args: args})
+}
+
+// ExecuteOptions matches the query against the next expectation and returns
+// its result, recording opts in the call.
+func (m *MockRunner) ExecuteOptions(ctx context.Context, opts QueryOptions, query string, args ...any) (QueryResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.execute(MockCall{Query: query, Args: args, Options: opts})
+}
+
+func (m *MockRunner) execute(call MockCall) (QueryResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	query, args := call.Query, call.Args
+	m.calls = append(m.calls, call)
+
+	var next *MockExpectation
+	for _, e := range m.expectations {
+		if !e.met {
+			next = e
+			break
+		}
+	}
+
+	if next == nil {
+		return nil, fmt.Errorf("mock: unexpected query %q with args %v", query, args)
+	}
+
+	if !next.anyQuery {
+		if next.query != query {
+			return nil, fmt.Errorf("mock: expected query %q, got %q", next.query, query)
+		}
+		if !argsEqual(next.args, args) {
+			return nil, fmt.Errorf("mock: expected args %v for query %q, got %v", next.args, query, args)
+		}
+	}
+
+	next.met = true
+
+	if next.err != nil {
+		return nil, next.err
+	}
+	if next.result == nil {
+		return emptyResult{}, nil
+	}
+	return next.result, nil
+}
+
+// ExecuteContext matches the query against the next expectation and returns its result.
+func (m *MockRunner) ExecuteContext(ctx context.Context, query string, args ...any) (QueryResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.Execute(query, args...)
+}
+
+// ExpectationsWereMet returns an error if any expected query was not executed.
+func (m *MockRunner) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.expectations {
+		if !e.met {
+			if e.anyQuery {
+				return fmt.Errorf("mock: enqueued result was not consumed")
+			}
+			return fmt.Errorf("mock: expected query %q with args %v was not executed", e.query, e.args)
+		}
+	}
+	return nil
+}
+
+// Calls returns all queries received by the runner, in order.
+func (m *MockRunner) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := make([]MockCall, len(m.calls))
+	copy(calls, m.calls)
+	return calls
+}
+
+// MockResult is a QueryResult serving canned rows. Rows are copied into the
+// destination through a JSON round trip, mirroring how the Couchbase SDK
+// decodes query results.
+type MockResult struct {
+	rows    []any
+	profile map[string]any
+}
+
+// NewMockResult returns a MockResult serving the given rows.
+func NewMockResult(rows ...any) *MockResult {
+	return &MockResult{rows: rows}
+}
+
+// WithProfile sets the profile section returned by Profile.
+func (r *MockResult) WithProfile(profile map[string]any) *MockResult {
+	r.profile = profile
+	return r
+}
+
+// Profile implements QueryResultMetadata. It returns ProfileNotAvailable if
+// no profile was set with WithProfile.
+func (r *MockResult) Profile() (map[string]any, error) {
+	if r.profile == nil {
+		return nil, ProfileNotAvailable
+	}
+	return r.profile, nil
+}
+
+// One decodes the first row into valuePtr.
+func (r *MockResult) One(valuePtr any) error {
+	if len(r.rows) == 0 {
+		return fmt.Errorf("mock: no rows in result")
+	}
+	return mockDecode(r.rows[0], valuePtr)
+}
+
+// All decodes all rows into slicePtr.
+func (r *MockResult) All(slicePtr any) error {
+	rows := r.rows
+	if rows == nil {
+		rows = []any{}
+	}
+	return mockDecode(rows, slicePtr)
+}
+
+// Close implements QueryResult.
+func (r *MockResult) Close() error {
+	return nil
+}
+
+func mockDecode(src any, dst any) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
@@ -0,0 +1,111 @@
+package n1qlizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func ExampleMockRunner() {
+	runner := NewMockRunner()
+	runner.ExpectQuery("SELECT name FROM users WHERE id = ?", 42).
+		WillReturn(NewMockResult(map[string]any{"name": "John"}))
+
+	result, err := Select("name").
+		From("users").
+		Where("id = ?", 42).
+		RunWith(runner).
+		Execute()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var user struct {
+		Name string `json:"name"`
+	}
+	if err := result.One(&user); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(user.Name)
+	fmt.Println(runner.ExpectationsWereMet())
+	// Output:
+	// John
+	// <nil>
+}
+
+func TestMockRunner(t *testing.T) {
+	t.Run("Query mismatch", func(t *testing.T) {
+		runner := NewMockRunner()
+		runner.ExpectQuery("SELECT * FROM users")
+
+		_, err := Select("*").From("orders").RunWith(runner).Execute()
+		if err == nil {
+			t.Fatal("Expected error for unexpected query, got nil")
+		}
+	})
+
+	t.Run("Args mismatch", func(t *testing.T) {
+		runner := NewMockRunner()
+		runner.ExpectQuery("SELECT * FROM users WHERE id = ?", 1)
+
+		_, err := Select("*").From("users").Where("id = ?", 2).RunWith(runner).Execute()
+		if err == nil {
+			t.Fatal("Expected error for mismatched args, got nil")
+		}
+	})
+
+	t.Run("Unmet expectations", func(t *testing.T) {
+		runner := NewMockRunner()
+		runner.ExpectQuery("SELECT * FROM users")
+		runner.ExpectQuery("SELECT * FROM orders")
+
+		if _, err := Select("*").From("users").RunWith(runner).Execute(); err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if err := runner.ExpectationsWereMet(); err == nil {
+			t.Error("Expected error for unmet expectation, got nil")
+		}
+	})
+
+	t.Run("Returns error", func(t *testing.T) {
+		runner := NewMockRunner()
+		expected := errors.New("timeout")
+		runner.ExpectQuery("DELETE FROM users").WillReturnError(expected)
+
+		_, err := Delete("users").RunWithContext(runner).ExecuteContext(context.Background())
+		if err != expected {
+			t.Errorf("Expected %v, got %v", expected, err)
+		}
+	})
+
+	t.Run("Enqueued results", func(t *testing.T) {
+		runner := NewMockRunner()
+		runner.Enqueue(NewMockResult(map[string]any{"id": 1}, map[string]any{"id": 2}))
+
+		result, err := Select("id").From("users").RunWith(runner).Execute()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		var rows []struct {
+			ID int `json:"id"`
+		}
+		if err := result.All(&rows); err != nil {
+			t.Fatalf("Failed to decode rows: %v", err)
+		}
+
+		if len(rows) != 2 || rows[0].ID != 1 || rows[1].ID != 2 {
+			t.Errorf("Wrong rows: %+v", rows)
+		}
+
+		calls := runner.Calls()
+		if len(calls) != 1 || calls[0].Query != "SELECT id FROM users" {
+			t.Errorf("Wrong calls: %+v", calls)
+		}
+	})
+}
@@ -6,7 +6,9 @@ package n1qlizer
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"strings"
+	"time"
 )
 
 // N1qlizer is the interface that wraps the ToN1ql method.
@@ -136,7 +138,38 @@ var Dollar = dollarFormat{}
 type dollarFormat struct{}
 
 func (dollarFormat) ReplacePlaceholders(sql string) (string, error) {
-	return replacePositionalPlaceholders(sql, "$")
+	return replacePositionalPlaceholders(sql, "$", 0)
+}
+
+func (dollarFormat) replacePlaceholdersFrom(sql string, offset int) (string, error) {
+	return replacePositionalPlaceholders(sql, "$", offset)
+}
+
+// Named is a PlaceholderFormat instance that replaces placeholders with
+// named parameters (e.g. $p1, $p2, $p3), for SDKs binding arguments by name.
+//
+// See the ToN1qlNamed method of the statement builders.
+var Named = namedFormat{}
+
+type namedFormat struct{}
+
+func (namedFormat) ReplacePlaceholders(sql string) (string, error) {
+	return replacePositionalPlaceholders(sql, "$p", 0)
+}
+
+// toN1qlNamed builds s, which must use the Named placeholder format, and
+// returns its args keyed by parameter name.
+func toN1qlNamed(s N1qlizer) (string, map[string]any, error) {
+	sql, args, err := s.ToN1ql()
+	if err != nil {
+		return "", nil, err
+	}
+
+	named := make(map[string]any, len(args))
+	for i, arg := range args {
+		named[fmt.Sprintf("p%d", i+1)] = arg
+	}
+	return sql, named, nil
 }
 
 type questionFormat struct{}
@@ -145,9 +178,26 @@ func (questionFormat) ReplacePlaceholders(sql string) (string, error) {
 	return sql, nil
 }
 
-func replacePositionalPlaceholders(sql, prefix string) (string, error) {
+// offsetPlaceholderFormat is implemented by placeholder formats that number
+// their placeholders and can therefore start numbering after an offset.
+type offsetPlaceholderFormat interface {
+	replacePlaceholdersFrom(sql string, offset int) (string, error)
+}
+
+// replacePlaceholders formats the placeholders of sql with f, numbering them
+// from offset+1 if f supports numbered placeholders.
+func replacePlaceholders(f PlaceholderFormat, sql string, offset int) (string, error) {
+	if offset > 0 {
+		if of, ok := f.(offsetPlaceholderFormat); ok {
+			return of.replacePlaceholdersFrom(sql, offset)
+		}
+	}
+	return f.ReplacePlaceholders(sql)
+}
+
+func replacePositionalPlaceholders(sql, prefix string, offset int) (string, error) {
 	buf := &bytes.Buffer{}
-	i := 0
+	i := offset
 	for {
 		p := strings.Index(sql, "?")
 		if p == -1 {
@@ -171,27 +221,134 @@ func replacePositionalPlaceholders(sql, prefix string) (string, error) {
 	return buf.String(), nil
 }
 
+// writeHints writes an optimizer hint comment, e.g. "/*+ INDEX(u idx) */ ".
+// Hints must not close the comment themselves.
+func writeHints(sql *bytes.Buffer, hints []string) error {
+	if len(hints) == 0 {
+		return nil
+	}
+
+	for _, h := range hints {
+		if strings.Contains(h, "*/") {
+			return fmt.Errorf("hint %q must not contain \"*/\"", h)
+		}
+	}
+
+	sql.WriteString("/*+ ")
+	sql.WriteString(strings.Join(hints, " "))
+	sql.WriteString(" */ ")
+	return nil
+}
+
+// checkQueryLength returns an error if sql is longer than max bytes. A max of
+// zero or less means unlimited.
+func checkQueryLength(sql string, max int) error {
+	if max > 0 && len(sql) > max {
+		return fmt.Errorf("query length %d exceeds maximum of %d bytes", len(sql), max)
+	}
+	return nil
+}
+
 // RunnerNotSet is returned by methods that need a Runner if it isn't set.
 var RunnerNotSet = fmt.Errorf("cannot run; no Runner set (RunWith)")
 
 // buildClauses is a helper function to build query clauses.
 func buildClauses(parts []N1qlizer, sql *bytes.Buffer, sep string, args []any) ([]any, error) {
-	for i, p := range parts {
+	written := false
+	for _, p := range parts {
 		partSQL, partArgs, err := p.ToN1ql()
 		if err != nil {
 			return nil, err
 		}
 		if len(partSQL) > 0 {
-			if i > 0 && len(sep) > 0 {
+			if written && len(sep) > 0 {
 				sql.WriteString(sep)
 			}
 			sql.WriteString(partSQL)
 			args = append(args, partArgs...)
+			written = true
 		}
 	}
 	return args, nil
 }
 
+// dedupeClauses renders the given parts and drops every part whose N1QL and
+// args are identical to an earlier one. The surviving parts keep their order.
+func dedupeClauses(parts []N1qlizer) ([]N1qlizer, error) {
+	type rendered struct {
+		sql  string
+		args []any
+	}
+
+	seen := make([]rendered, 0, len(parts))
+	result := make([]N1qlizer, 0, len(parts))
+	for _, p := range parts {
+		partSQL, partArgs, err := p.ToN1ql()
+		if err != nil {
+			return nil, err
+		}
+
+		duplicate := false
+		for _, r := range seen {
+			if r.sql == partSQL && argsEqual(r.args, partArgs) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		seen = append(seen, rendered{sql: partSQL, args: partArgs})
+		result = append(result, expr{sql: partSQL, args: partArgs})
+	}
+	return result, nil
+}
+
+// formatTimeArgs returns a copy of args with time.Time and *time.Time args
+// replaced by their RFC 3339 representation, and zero times and nil pointers
+// by nil.
+func formatTimeArgs(args []any) []any {
+	formatted := make([]any, len(args))
+	for i, arg := range args {
+		switch t := arg.(type) {
+		case time.Time:
+			formatted[i] = formatTime(t)
+		case *time.Time:
+			if t != nil {
+				formatted[i] = formatTime(*t)
+			}
+		default:
+			formatted[i] = arg
+		}
+	}
+	return formatted
+}
+
+func formatTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// argsEqual compares two arg slices, treating nil and empty slices as equal.
+func argsEqual(expected, actual []any) bool {
+	if len(expected) == 0 && len(actual) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+// nestedToN1ql renders a N1qlizer embedded in another query. Placeholders are
+// left unformatted when possible so the outer query can number them.
+func nestedToN1ql(s N1qlizer) (string, []any, error) {
+	if raw, ok := s.(rawN1qlizer); ok {
+		return raw.toN1qlRaw()
+	}
+	return s.ToN1ql()
+}
+
 // StatementBuilderType is the type of StatementBuilder.
 type StatementBuilderType Builder
 
@@ -200,6 +357,28 @@ func (b StatementBuilderType) Select(columns ...string) SelectBuilder {
 	return SelectBuilder(b).Columns(columns...)
 }
 
+// SelectRaw returns a SelectBuilder for a SELECT RAW query for this
+// StatementBuilderType.
+func (b StatementBuilderType) SelectRaw(expr any, args ...any) SelectBuilder {
+	return SelectBuilder(b).SelectRaw(expr, args...)
+}
+
+// SelectByKeys returns a SelectBuilder for this StatementBuilderType that
+// fetches the documents of bucket with the given keys, each bound as an arg,
+// returning their key as "id" next to their fields:
+//
+//	SELECT META(d).id AS id, d.* FROM bucket AS d USE KEYS [?, ?]
+//
+// The keyspace is aliased as d so that bucket may be any keyspace, such as a
+// backticked or fully-qualified name.
+func (b StatementBuilderType) SelectByKeys(bucket string, keys ...any) SelectBuilder {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+	return b.Select("META(d).id AS id").
+		SelfColumn("d").
+		From(bucket+" AS d").
+		UseKeys("["+placeholders+"]", keys...)
+}
+
 // Insert returns a InsertBuilder for this StatementBuilderType.
 func (b StatementBuilderType) Insert(into string) InsertBuilder {
 	return InsertBuilder(b).Into(into)
@@ -221,12 +400,66 @@ func (b StatementBuilderType) Delete(from string) DeleteBuilder {
 	return DeleteBuilder(b).From(from)
 }
 
+// Merge returns a MergeBuilder for this StatementBuilderType.
+func (b StatementBuilderType) Merge(into string) MergeBuilder {
+	return MergeBuilder(b).Into(into)
+}
+
+// InsertIntoCollection returns an InsertBuilder for this StatementBuilderType
+// into the quoted keyspace bucket.scope.collection. See CollectionKeyspace.
+func (b StatementBuilderType) InsertIntoCollection(bucket, scope, collection string) InsertBuilder {
+	return b.Insert(CollectionKeyspace(bucket, scope, collection))
+}
+
+// UpsertIntoCollection returns an UpsertBuilder for this StatementBuilderType
+// into the quoted keyspace bucket.scope.collection. See CollectionKeyspace.
+func (b StatementBuilderType) UpsertIntoCollection(bucket, scope, collection string) UpsertBuilder {
+	return b.Upsert(CollectionKeyspace(bucket, scope, collection))
+}
+
+// UpdateCollection returns an UpdateBuilder for this StatementBuilderType on
+// the quoted keyspace bucket.scope.collection. See CollectionKeyspace.
+func (b StatementBuilderType) UpdateCollection(bucket, scope, collection string) UpdateBuilder {
+	return b.Update(CollectionKeyspace(bucket, scope, collection))
+}
+
+// DeleteFromCollection returns a DeleteBuilder for this StatementBuilderType
+// on the quoted keyspace bucket.scope.collection. See CollectionKeyspace.
+func (b StatementBuilderType) DeleteFromCollection(bucket, scope, collection string) DeleteBuilder {
+	return b.Delete(CollectionKeyspace(bucket, scope, collection))
+}
+
 // AnalyticsSelect returns an AnalyticsSelectBuilder for this StatementBuilderType.
 // This is specific to the Couchbase Analytics Service.
 func (b StatementBuilderType) AnalyticsSelect(columns ...string) AnalyticsSelectBuilder {
 	return AnalyticsSelectBuilder(b).Columns(columns...)
 }
 
+// Infer returns an InferBuilder for this StatementBuilderType.
+func (b StatementBuilderType) Infer(keyspace string) InferBuilder {
+	return InferBuilder(b).Keyspace(keyspace)
+}
+
+// CreateIndex returns a CreateIndexBuilder for this StatementBuilderType.
+func (b StatementBuilderType) CreateIndex(name, keyspace string) CreateIndexBuilder {
+	return CreateIndexBuilder(b).Name(name).Keyspace(keyspace)
+}
+
+// DropIndex returns a DropIndexBuilder for this StatementBuilderType.
+func (b StatementBuilderType) DropIndex(name, keyspace string) DropIndexBuilder {
+	return DropIndexBuilder(b).Name(name).Keyspace(keyspace)
+}
+
+// CreatePrimaryIndex returns a CreatePrimaryIndexBuilder for this StatementBuilderType.
+func (b StatementBuilderType) CreatePrimaryIndex(keyspace string) CreatePrimaryIndexBuilder {
+	return CreatePrimaryIndexBuilder(b).Keyspace(keyspace)
+}
+
+// BuildIndex returns a BuildIndexBuilder for this StatementBuilderType.
+func (b StatementBuilderType) BuildIndex(keyspace string, names ...string) BuildIndexBuilder {
+	return BuildIndexBuilder(b).Keyspace(keyspace).Indexes(names...)
+}
+
 // PlaceholderFormat sets the PlaceholderFormat for this StatementBuilderType.
 func (b StatementBuilderType) PlaceholderFormat(f PlaceholderFormat) StatementBuilderType {
 	// Use generics to set the placeholder format
@@ -250,6 +483,183 @@ func (b StatementBuilderType) RunWithContext(runner QueryRunnerContext) Statemen
 	return newB
 }
 
+// ExpandDefaultCollection makes the builders created from this
+// StatementBuilderType qualify bare bucket names in FROM, INTO and UPDATE
+// keyspaces with the default scope and collection, e.g. "users u" becomes
+// "users._default._default u", for clusters that require fully-qualified
+// keyspaces. Keyspaces that already name a scope, a collection or a namespace,
+// e.g. "system:indexes", are left as is.
+func (b StatementBuilderType) ExpandDefaultCollection(expand bool) StatementBuilderType {
+	return Set[StatementBuilderType, bool](b, "ExpandDefaultCollection", expand)
+}
+
+// Namespace makes the builders created from this StatementBuilderType prefix
+// the keyspaces they read from or write to (FROM, INTO, UPDATE, DELETE FROM
+// and MERGE INTO targets, and the keyspaces of INFER and index statements)
+// with the namespace ns, e.g. "users u" becomes "default:users u". Keyspaces
+// that already name a namespace, such as "system:indexes", are left as is, and
+// an empty ns disables the prefix. To apply it to the package-level builder
+// functions, set it on StatementBuilder:
+//
+//	n1qlizer.StatementBuilder = n1qlizer.StatementBuilder.Namespace("default")
+func (b StatementBuilderType) Namespace(ns string) StatementBuilderType {
+	return Set[StatementBuilderType, string](b, "Namespace", ns)
+}
+
+// namespaceKeyspaces prefixes the keyspace that leads each term of a
+// comma-separated list of FROM terms with "ns:", unless it already names a
+// namespace or ns is empty. Terms that do not start with a keyspace, such as
+// subqueries, array literals and function calls, are left as is.
+func namespaceKeyspaces(keyspaces, ns string) string {
+	if len(ns) == 0 {
+		return keyspaces
+	}
+
+	terms := splitTerms(keyspaces)
+	for i, term := range terms {
+		lead, name, rest := splitKeyspaceTerm(term)
+		if !isKeyspacePath(name) || hasUnquoted(name, ':') {
+			continue
+		}
+		terms[i] = lead + ns + ":" + name + rest
+	}
+	return strings.Join(terms, ",")
+}
+
+// splitTerms splits s on the commas outside of brackets and quotes.
+func splitTerms(s string) []string {
+	var terms []string
+	start := 0
+	for _, i := range topLevelIndices(s, ",") {
+		terms = append(terms, s[start:i])
+		start = i + 1
+	}
+	return append(terms, s[start:])
+}
+
+// splitKeyspaceTerm splits a FROM term into its leading whitespace, its first
+// token, which names the keyspace if the term reads from one, and the rest of
+// the term, e.g. an alias.
+func splitKeyspaceTerm(term string) (lead, name, rest string) {
+	trimmed := strings.TrimLeft(term, " \t\n")
+	lead, name = term[:len(term)-len(trimmed)], trimmed
+	if i := topLevelIndices(trimmed, " \t\n"); len(i) > 0 {
+		name, rest = trimmed[:i[0]], trimmed[i[0]:]
+	}
+	return
+}
+
+// topLevelIndices returns the indices of the bytes of s that are one of seps
+// and lie outside of brackets and quotes.
+func topLevelIndices(s, seps string) []int {
+	var indices []int
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"', '`':
+			end := closingQuote(s, i)
+			if end < 0 {
+				return indices
+			}
+			i = end
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		default:
+			if depth == 0 && strings.IndexByte(seps, c) >= 0 {
+				indices = append(indices, i)
+			}
+		}
+	}
+	return indices
+}
+
+// isKeyspacePath reports whether name is a keyspace path: identifiers or
+// backticked names separated by "." or ":", e.g. "users", "`b`.`s`.`c`" or
+// "system:indexes".
+func isKeyspacePath(name string) bool {
+	segment := true
+	for i := 0; i < len(name); {
+		switch {
+		case !segment && (name[i] == '.' || name[i] == ':'):
+			i++
+			segment = true
+			continue
+		case !segment:
+			return false
+		case name[i] == '`':
+			end := closingQuote(name, i)
+			if end < 0 {
+				return false
+			}
+			i = end + 1
+		case isIdentByte(name[i]) && !(name[i] >= '0' && name[i] <= '9'):
+			for i < len(name) && isIdentByte(name[i]) {
+				i++
+			}
+		default:
+			return false
+		}
+		segment = false
+	}
+	return !segment
+}
+
+// hasUnquoted reports whether s contains c outside backticks.
+func hasUnquoted(s string, c rune) bool {
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '`':
+			quoted = !quoted
+		case r == c && !quoted:
+			return true
+		}
+	}
+	return false
+}
+
+// qualifyKeyspaces appends "._default._default" to the keyspace that leads
+// each term of a comma-separated list of FROM terms when it is a bare bucket
+// name, keeping any alias that follows it. Keyspaces that name a scope,
+// collection or namespace, such as "system:indexes", and terms that do not
+// start with a keyspace, such as subqueries, array literals and function
+// calls, are left as is.
+func qualifyKeyspaces(keyspaces string) string {
+	terms := splitTerms(keyspaces)
+	for i, term := range terms {
+		lead, name, rest := splitKeyspaceTerm(term)
+		if !isKeyspacePath(name) || hasUnquoted(name, '.') || hasUnquoted(name, ':') {
+			continue
+		}
+		terms[i] = lead + name + "._default._default" + rest
+	}
+	return strings.Join(terms, ",")
+}
+
+// FormatTimes makes the builders created from this StatementBuilderType bind
+// time.Time and *time.Time args as RFC 3339 strings.
+func (b StatementBuilderType) FormatTimes() StatementBuilderType {
+	return Set[StatementBuilderType, bool](b, "FormatTimes", true)
+}
+
+// StrictMode makes the SELECT, UPDATE, DELETE and MERGE builders created from
+// this StatementBuilderType reject expressions that look like they have
+// unbound user input concatenated into them. See SelectBuilder.StrictMode.
+func (b StatementBuilderType) StrictMode() StatementBuilderType {
+	return Set[StatementBuilderType, bool](b, "Strict", true)
+}
+
+// StrictPaging makes the SELECT, UPDATE, DELETE and Analytics SELECT builders
+// created from this StatementBuilderType reject an OFFSET without a LIMIT.
+// See SelectBuilder.StrictPaging.
+func (b StatementBuilderType) StrictPaging() StatementBuilderType {
+	return Set[StatementBuilderType, bool](b, "StrictPaging", true)
+}
+
 // StatementBuilder is a parent builder for other statement builders.
 var Question = questionFormat{}
 var StatementBuilder = StatementBuilderType(EmptyBuilder).PlaceholderFormat(Question)
@@ -261,6 +671,21 @@ func Select(columns ...string) SelectBuilder {
 	return StatementBuilder.Select(columns...)
 }
 
+// SelectRaw returns a new SelectBuilder for a SELECT RAW query.
+//
+// See SelectBuilder.SelectRaw.
+func SelectRaw(expr any, args ...any) SelectBuilder {
+	return StatementBuilder.SelectRaw(expr, args...)
+}
+
+// SelectByKeys returns a new SelectBuilder fetching the documents of bucket
+// with the given keys.
+//
+// See StatementBuilderType.SelectByKeys.
+func SelectByKeys(bucket string, keys ...any) SelectBuilder {
+	return StatementBuilder.SelectByKeys(bucket, keys...)
+}
+
 // Insert returns a new InsertBuilder with the given table name.
 //
 // See InsertBuilder.Into.
@@ -288,3 +713,88 @@ func Update(table string) UpdateBuilder {
 func Delete(from string) DeleteBuilder {
 	return StatementBuilder.Delete(from)
 }
+
+// Merge returns a new MergeBuilder with the given target keyspace.
+//
+// See MergeBuilder.Into.
+func Merge(into string) MergeBuilder {
+	return StatementBuilder.Merge(into)
+}
+
+// InsertIntoCollection returns a new InsertBuilder into the quoted keyspace
+// bucket.scope.collection.
+//
+// See CollectionKeyspace.
+func InsertIntoCollection(bucket, scope, collection string) InsertBuilder {
+	return StatementBuilder.InsertIntoCollection(bucket, scope, collection)
+}
+
+// UpsertIntoCollection returns a new UpsertBuilder into the quoted keyspace
+// bucket.scope.collection.
+//
+// See CollectionKeyspace.
+func UpsertIntoCollection(bucket, scope, collection string) UpsertBuilder {
+	return StatementBuilder.UpsertIntoCollection(bucket, scope, collection)
+}
+
+// UpdateCollection returns a new UpdateBuilder on the quoted keyspace
+// bucket.scope.collection.
+//
+// See CollectionKeyspace.
+func UpdateCollection(bucket, scope, collection string) UpdateBuilder {
+	return StatementBuilder.UpdateCollection(bucket, scope, collection)
+}
+
+// DeleteFromCollection returns a new DeleteBuilder on the quoted keyspace
+// bucket.scope.collection.
+//
+// See CollectionKeyspace.
+func DeleteFromCollection(bucket, scope, collection string) DeleteBuilder {
+	return StatementBuilder.DeleteFromCollection(bucket, scope, collection)
+}
+
+// CollectionKeyspace returns the fully-qualified keyspace of a collection
+// with each part quoted in backticks, so that names with special characters
+// such as "travel-sample" are valid, e.g. CollectionKeyspace("travel-sample",
+// "inventory", "airline") returns "`travel-sample`.`inventory`.`airline`".
+// Backticks inside a name are escaped by doubling them.
+func CollectionKeyspace(bucket, scope, collection string) string {
+	parts := []string{bucket, scope, collection}
+	for i, p := range parts {
+		parts[i] = "`" + strings.ReplaceAll(p, "`", "``") + "`"
+	}
+	return strings.Join(parts, ".")
+}
+
+// Infer returns a new InferBuilder with the given keyspace.
+//
+// See InferBuilder.Keyspace.
+func Infer(keyspace string) InferBuilder {
+	return StatementBuilder.Infer(keyspace)
+}
+
+// CreateIndex returns a new CreateIndexBuilder for an index with the given
+// name on the given keyspace.
+//
+// See CreateIndexBuilder.On.
+func CreateIndex(name, keyspace string) CreateIndexBuilder {
+	return StatementBuilder.CreateIndex(name, keyspace)
+}
+
+// DropIndex returns a new DropIndexBuilder for the index with the given name
+// on the given keyspace.
+func DropIndex(name, keyspace string) DropIndexBuilder {
+	return StatementBuilder.DropIndex(name, keyspace)
+}
+
+// CreatePrimaryIndex returns a new CreatePrimaryIndexBuilder for the given
+// keyspace.
+func CreatePrimaryIndex(keyspace string) CreatePrimaryIndexBuilder {
+	return StatementBuilder.CreatePrimaryIndex(keyspace)
+}
+
+// BuildIndex returns a new BuildIndexBuilder building the named deferred
+// indexes of the given keyspace.
+func BuildIndex(keyspace string, names ...string) BuildIndexBuilder {
+	return StatementBuilder.BuildIndex(keyspace, names...)
+}
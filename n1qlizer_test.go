@@ -3,6 +3,7 @@ package n1qlizer
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestStatementBuilder(t *testing.T) {
@@ -268,3 +269,355 @@ func TestDollarFormat(t *testing.T) {
 		})
 	}
 }
+
+// TestToN1qlNamed tests building queries with named parameters
+func TestToN1qlNamed(t *testing.T) {
+	sql, named, err := Select("*").
+		From("users").
+		Where("name = ?", "John").
+		Where("age > ?", 18).
+		ToN1qlNamed()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "SELECT * FROM users WHERE name = $p1 AND age > $p2" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	if len(named) != 2 || named["p1"] != "John" || named["p2"] != 18 {
+		t.Errorf("Wrong named args: %+v", named)
+	}
+
+	sql, named, err = Update("users").Set("name", "Jane").Where("id = ?", 1).ToN1qlNamed()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "UPDATE users SET name = $p1 WHERE id = $p2" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	if len(named) != 2 || named["p1"] != "Jane" || named["p2"] != 1 {
+		t.Errorf("Wrong named args: %+v", named)
+	}
+}
+
+// TestMaxQueryLength tests rejecting queries longer than the configured maximum
+func TestMaxQueryLength(t *testing.T) {
+	query := Select("*").From("users").Where("id = ?", 1)
+
+	if _, _, err := query.MaxQueryLength(100).ToN1ql(); err != nil {
+		t.Errorf("Unexpected error for short query: %v", err)
+	}
+
+	if _, _, err := query.MaxQueryLength(10).ToN1ql(); err == nil {
+		t.Error("Expected error for query exceeding maximum length, got nil")
+	}
+
+	if _, _, err := query.ToN1ql(); err != nil {
+		t.Errorf("Unexpected error for unlimited query: %v", err)
+	}
+
+	if _, _, err := Delete("users").Where(Eq{"id": []any{1, 2, 3, 4, 5}}).MaxQueryLength(20).ToN1ql(); err == nil {
+		t.Error("Expected error for DELETE exceeding maximum length, got nil")
+	}
+}
+
+func TestArgs(t *testing.T) {
+	testCases := []struct {
+		name    string
+		builder interface{ Args() ([]any, error) }
+		args    []interface{}
+	}{
+		{"Select", Select("*").From("users").Where("age > ?", 18).Where(Eq{"status": "active"}), []interface{}{18, "active"}},
+		{"Insert", Insert("users").Columns("id", "name").Values(1, "John"), []interface{}{1, "John"}},
+		{"Upsert", Upsert("users").Document("user1", "John"), []interface{}{"user1", "John"}},
+		{"Update", Update("users").Set("name", "John").Where("id = ?", 1), []interface{}{"John", 1}},
+		{"Delete", Delete("users").Where("id = ?", 1), []interface{}{1}},
+		{"Analytics", AnalyticsSelect("*").From("users").Where("age > ?", 18), []interface{}{18}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args, err := tc.builder.Args()
+			if err != nil {
+				t.Fatalf("Failed to get args: %v", err)
+			}
+
+			if !argsEqual(tc.args, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tc.args, args)
+			}
+		})
+	}
+
+	if _, err := Select("*").Where("age > ?").Args(); err == nil {
+		t.Error("Expected error for invalid query, got nil")
+	}
+}
+
+func TestExpandDefaultCollection(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Question)
+	expanded := sb.ExpandDefaultCollection(true)
+
+	tests := []struct {
+		name     string
+		builder  N1qlizer
+		expected string
+	}{
+		{"select bare", sb.Select("*").From("users u"), "SELECT * FROM users u"},
+		{"select expanded", expanded.Select("*").From("users u"), "SELECT * FROM users._default._default u"},
+		{"select qualified", expanded.Select("*").From("users.inventory.orders o"), "SELECT * FROM users.inventory.orders o"},
+		{"select backticked", expanded.Select("*").From("`travel-sample`"), "SELECT * FROM `travel-sample`._default._default"},
+		{"select system keyspace", expanded.Select("*").From("system:indexes"), "SELECT * FROM system:indexes"},
+		{"select namespaced", expanded.Select("*").From("ns:bucket b"), "SELECT * FROM ns:bucket b"},
+		{"select function term", expanded.Select("n").From("ARRAY_RANGE(0, 3) AS n"), "SELECT n FROM ARRAY_RANGE(0, 3) AS n"},
+		{"select subquery term", expanded.Select("*").From("(SELECT a, b FROM x) t"), "SELECT * FROM (SELECT a, b FROM x) t"},
+		{"select array term", expanded.Select("n").From("[1,2] AS n"), "SELECT n FROM [1,2] AS n"},
+		{"select use index", expanded.Select("*").From("b USE INDEX (ix1, ix2)"), "SELECT * FROM b._default._default USE INDEX (ix1, ix2)"},
+		{"select multi", expanded.Select("*").FromMulti("users u", "orders o"), "SELECT * FROM users._default._default u, orders._default._default o"},
+		{"insert bare", sb.Insert("users").Columns("KEY", "VALUE").Values("k", "v"), "INSERT INTO users (KEY, VALUE) VALUES (?, ?)"},
+		{"insert expanded", expanded.Insert("users").Columns("KEY", "VALUE").Values("k", "v"), "INSERT INTO users._default._default (KEY, VALUE) VALUES (?, ?)"},
+		{"upsert expanded", expanded.Upsert("users").Document("k", "v"), "UPSERT INTO users._default._default (KEY, VALUE) VALUES (?, ?)"},
+		{"update expanded", expanded.Update("users").Set("a", 1), "UPDATE users._default._default SET a = ?"},
+		{"delete bare", sb.Delete("users").Where("a = ?", 1), "DELETE FROM users WHERE a = ?"},
+		{"update namespaced", expanded.Update("default:users").Set("a", 1), "UPDATE default:users SET a = ?"},
+		{"delete expanded", expanded.Delete("users").Where("a = ?", 1), "DELETE FROM users._default._default WHERE a = ?"},
+		{"merge expanded", expanded.Merge("users t").Using([]any{1}, "s").On("t.id = s").WhenMatchedDelete(),
+			"MERGE INTO users._default._default t USING [?] AS s ON t.id = s WHEN MATCHED THEN DELETE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+		})
+	}
+}
+
+func TestFormatTimes(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	var zero time.Time
+	var nilTime *time.Time
+
+	sql, args, err := Select("*").From("events").
+		Where("created >= ?", created).
+		Where("updated < ?", &created).
+		Where("deleted = ?", zero).
+		Where("archived = ?", nilTime).
+		FormatTimes().
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM events WHERE created >= ? AND updated < ? AND deleted = ? AND archived = ?"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"2024-03-01T12:30:00Z", "2024-03-01T12:30:00Z", nil, nil}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+
+	_, args, err = Select("*").From("events").Where("created >= ?", created).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	if args[0] != created {
+		t.Errorf("Expected time.Time arg without FormatTimes, got %v", args[0])
+	}
+
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar).FormatTimes()
+	_, args, err = sb.Update("events").Set("seen", created.Add(1500*time.Millisecond)).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expectedArgs = []interface{}{"2024-03-01T12:30:01.5Z"}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+func TestStrictPaging(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Question)
+	strict := sb.StrictPaging()
+
+	tests := []struct {
+		name    string
+		builder N1qlizer
+		wantErr bool
+	}{
+		{"select offset without limit", strict.Select("*").From("users").Offset(10), true},
+		{"select offset expr without limit", strict.Select("*").From("users").OffsetExpr(Expr("?", 10)), true},
+		{"select offset with limit", strict.Select("*").From("users").Limit(5).Offset(10), false},
+		{"select offset with default limit", strict.Select("*").From("users").DefaultLimit(20).Offset(10), false},
+		{"select offset not strict", sb.Select("*").From("users").Offset(10), false},
+		{"select builder flag", sb.Select("*").From("users").Offset(10).StrictPaging(), true},
+		{"update offset without limit", strict.Update("users").Set("a", 1).Offset(10), true},
+		{"update offset with limit", strict.Update("users").Set("a", 1).Limit(5).Offset(10), false},
+		{"delete offset without limit", strict.Delete("users").Offset(10), true},
+		{"delete offset with limit", strict.Delete("users").Limit(5).Offset(10), false},
+		{"analytics offset without limit", strict.AnalyticsSelect("*").From("users").Offset(10), true},
+		{"analytics offset with limit", strict.AnalyticsSelect("*").From("users").Limit(5).Offset(10), false},
+		{"analytics builder flag", sb.AnalyticsSelect("*").From("users").Offset(10).StrictPaging(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.builder.ToN1ql()
+			if tt.wantErr && err == nil {
+				t.Error("Expected error for OFFSET without LIMIT, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCollectionTargets(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Question)
+	expanded := sb.ExpandDefaultCollection(true)
+
+	tests := []struct {
+		name     string
+		builder  N1qlizer
+		expected string
+	}{
+		{"insert", sb.InsertIntoCollection("travel-sample", "inventory", "airline").Columns("KEY", "VALUE").Values("k", "v"),
+			"INSERT INTO `travel-sample`.`inventory`.`airline` (KEY, VALUE) VALUES (?, ?)"},
+		{"upsert", sb.UpsertIntoCollection("my-app", "_default", "users").Document("k", "v"),
+			"UPSERT INTO `my-app`.`_default`.`users` (KEY, VALUE) VALUES (?, ?)"},
+		{"update", sb.UpdateCollection("my-app", "tenant.1", "users").Set("a", 1).Where("b = ?", 2),
+			"UPDATE `my-app`.`tenant.1`.`users` SET a = ? WHERE b = ?"},
+		{"delete", sb.DeleteFromCollection("odd`name", "s", "c").Where("a = ?", 1),
+			"DELETE FROM `odd``name`.`s`.`c` WHERE a = ?"},
+		{"expanded is not requalified", expanded.DeleteFromCollection("my-app", "s", "c").Where("a = ?", 1),
+			"DELETE FROM `my-app`.`s`.`c` WHERE a = ?"},
+		{"package level", UpdateCollection("b", "s", "c").Set("a", 1),
+			"UPDATE `b`.`s`.`c` SET a = ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+		})
+	}
+}
+
+func TestExtraArgs(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+	opts := map[string]any{"scan_consistency": "request_plus"}
+
+	tests := []struct {
+		name         string
+		builder      N1qlizer
+		expected     string
+		expectedArgs []any
+	}{
+		{"select", sb.Select("*").From("users").Where("id = ?", 1).ExtraArgs(opts).ExtraArgs("trace"),
+			"SELECT * FROM users WHERE id = $1", []any{1, opts, "trace"}},
+		{"insert", sb.Insert("users").Columns("KEY", "VALUE").Values("k", "v").ExtraArgs(opts),
+			"INSERT INTO users (KEY, VALUE) VALUES ($1, $2)", []any{"k", "v", opts}},
+		{"upsert", sb.Upsert("users").Document("k", "v").ExtraArgs(opts),
+			"UPSERT INTO users (KEY, VALUE) VALUES ($1, $2)", []any{"k", "v", opts}},
+		{"update", sb.Update("users").Set("a", 1).ExtraArgs(opts),
+			"UPDATE users SET a = $1", []any{1, opts}},
+		{"delete", sb.Delete("users").Where("a = ?", 1).ExtraArgs(opts),
+			"DELETE FROM users WHERE a = $1", []any{1, opts}},
+		{"merge", sb.Merge("users t").Using([]any{1}, "s").On("t.id = s").WhenMatchedDelete().ExtraArgs(opts),
+			"MERGE INTO users t USING [$1] AS s ON t.id = s WHEN MATCHED THEN DELETE", []any{1, opts}},
+		{"analytics", sb.AnalyticsSelect("*").From("users").Where("a = ?", 1).ExtraArgs(opts),
+			"SELECT * FROM users WHERE a = $1", []any{1, opts}},
+		{"not added to subqueries", sb.Select("*").From("users").Where(In("id", sb.Select("uid").From("orders").Where("total > ?", 5).ExtraArgs(opts))),
+			"SELECT * FROM users WHERE id IN (SELECT uid FROM orders WHERE total > $1)", []any{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tt.expectedArgs, args)
+			}
+		})
+	}
+
+	args, err := sb.Select("*").From("users").Where("id = ?", 1).ExtraArgs("trace").Args()
+	if err != nil {
+		t.Fatalf("Failed to get args: %v", err)
+	}
+	if !argsEqual([]any{1, "trace"}, args) {
+		t.Errorf("Wrong args from Args: %v", args)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Question)
+	ns := sb.Namespace("default")
+
+	tests := []struct {
+		name     string
+		builder  N1qlizer
+		expected string
+	}{
+		{"select", ns.Select("*").From("users u"), "SELECT * FROM default:users u"},
+		{"select multi", ns.Select("*").FromMulti("users u", "`travel-sample` t"), "SELECT * FROM default:users u, default:`travel-sample` t"},
+		{"select already namespaced", ns.Select("*").From("system:indexes"), "SELECT * FROM system:indexes"},
+		{"select backticked colon", ns.Select("*").From("`a:b`"), "SELECT * FROM default:`a:b`"},
+		{"select subquery", ns.Select("*").FromSelect(sb.Select("*").From("users"), "t"), "SELECT * FROM (SELECT * FROM users) AS t"},
+		{"select without namespace", sb.Select("*").From("users"), "SELECT * FROM users"},
+		{"select subquery term", ns.Select("*").From("(SELECT a, b FROM x) t"), "SELECT * FROM (SELECT a, b FROM x) t"},
+		{"select array term", ns.Select("n").From("[1,2] AS n"), "SELECT n FROM [1,2] AS n"},
+		{"select function term", ns.Select("n").From("ARRAY_RANGE(0, 3) AS n"), "SELECT n FROM ARRAY_RANGE(0, 3) AS n"},
+		{"select use index", ns.Select("*").From("b USE INDEX (ix1, ix2)"), "SELECT * FROM default:b USE INDEX (ix1, ix2)"},
+		{"select mixed terms", ns.Select("*").From("users u, [1,2] AS n"), "SELECT * FROM default:users u, [1,2] AS n"},
+		{"select expanded", ns.ExpandDefaultCollection(true).Select("*").From("users u"), "SELECT * FROM default:users._default._default u"},
+		{"insert", ns.Insert("users").Columns("KEY", "VALUE").Values("k", "v"), "INSERT INTO default:users (KEY, VALUE) VALUES (?, ?)"},
+		{"insert collection", ns.InsertIntoCollection("a,b", "s", "c").Columns("KEY", "VALUE").Values("k", "v"),
+			"INSERT INTO default:`a,b`.`s`.`c` (KEY, VALUE) VALUES (?, ?)"},
+		{"upsert", ns.Upsert("users").Document("k", "v"), "UPSERT INTO default:users (KEY, VALUE) VALUES (?, ?)"},
+		{"update", ns.Update("users").Set("a", 1), "UPDATE default:users SET a = ?"},
+		{"update collection", ns.UpdateCollection("b", "s", "c").Set("a", 1), "UPDATE default:`b`.`s`.`c` SET a = ?"},
+		{"delete", ns.Delete("users").Where("a = ?", 1), "DELETE FROM default:users WHERE a = ?"},
+		{"delete already namespaced", ns.Delete("other:users"), "DELETE FROM other:users"},
+		{"infer", ns.Infer("users"), "INFER default:users"},
+		{"create index", ns.CreateIndex("idx_age", "users").On("age"), "CREATE INDEX `idx_age` ON default:users(age)"},
+		{"drop index", ns.DropIndex("idx_age", "users"), "DROP INDEX `idx_age` ON default:users"},
+		{"create primary index", ns.CreatePrimaryIndex("users"), "CREATE PRIMARY INDEX ON default:users"},
+		{"build index", ns.BuildIndex("users", "idx_age"), "BUILD INDEX ON default:users(`idx_age`)"},
+		{"merge", ns.Merge("users t").Using([]any{"k"}, "s").On("META(t).id = s").WhenMatchedDelete(),
+			"MERGE INTO default:users t USING [?] AS s ON META(t).id = s WHEN MATCHED THEN DELETE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+		})
+	}
+}
@@ -2,10 +2,12 @@ package n1qlizer
 
 import (
 	"fmt"
+	"strings"
 )
 
 // NestClause represents a NEST clause in a N1QL query
 type NestClause struct {
+	join      string
 	bucket    string
 	alias     string
 	onKeys    string
@@ -18,6 +20,9 @@ func (n NestClause) ToN1ql() (string, []interface{}, error) {
 	var args []interface{}
 
 	result = fmt.Sprintf("NEST %s", n.bucket)
+	if n.join != "" {
+		result = n.join + " " + result
+	}
 
 	if n.alias != "" {
 		result += fmt.Sprintf(" AS %s", n.alias)
@@ -28,7 +33,7 @@ func (n NestClause) ToN1ql() (string, []interface{}, error) {
 	}
 
 	if n.condition != nil {
-		sql, condArgs, err := n.condition.ToN1ql()
+		sql, condArgs, err := nestedToN1ql(n.condition)
 		if err != nil {
 			return "", nil, err
 		}
@@ -47,20 +52,35 @@ func Nest(bucket string) NestClause {
 	return NestClause{bucket: bucket}
 }
 
+// InnerNest creates a new INNER NEST clause, the explicit spelling of NEST.
+func InnerNest(bucket string) NestClause {
+	return NestClause{join: "INNER", bucket: bucket}
+}
+
 // As sets the alias for the nested bucket
 func (n NestClause) As(alias string) NestClause {
 	n.alias = alias
 	return n
 }
 
+// Alias returns the alias set with As, or an empty string.
+func (n NestClause) Alias() string {
+	return n.alias
+}
+
 // OnKeys sets the ON KEYS expression for the NEST clause
 func (n NestClause) OnKeys(keys string) NestClause {
 	n.onKeys = keys
 	return n
 }
 
-// On sets the ON condition for the NEST clause
+// On sets the ON condition for the NEST clause. A nil condition leaves the
+// clause unchanged.
 func (n NestClause) On(condition interface{}, args ...interface{}) NestClause {
+	if condition == nil {
+		return n
+	}
+
 	switch c := condition.(type) {
 	case string:
 		n.condition = Expr(c, args...)
@@ -117,8 +137,18 @@ func (u UnnestClause) As(alias string) UnnestClause {
 	return u
 }
 
-// On sets the ON condition for the UNNEST clause
+// Alias returns the alias set with As, or an empty string.
+func (u UnnestClause) Alias() string {
+	return u.alias
+}
+
+// On sets the ON condition for the UNNEST clause. A nil condition leaves the
+// clause unchanged.
 func (u UnnestClause) On(condition interface{}, args ...interface{}) UnnestClause {
+	if condition == nil {
+		return u
+	}
+
 	switch c := condition.(type) {
 	case string:
 		u.condition = Expr(c, args...)
@@ -134,6 +164,7 @@ func (u UnnestClause) On(condition interface{}, args ...interface{}) UnnestClaus
 // LeftNestClause represents a LEFT NEST clause in a N1QL query
 type LeftNestClause struct {
 	nestClause NestClause
+	outer      bool
 }
 
 // ToN1ql implements the N1qlizer interface
@@ -143,6 +174,9 @@ func (ln LeftNestClause) ToN1ql() (string, []interface{}, error) {
 		return "", nil, err
 	}
 
+	if ln.outer {
+		return "LEFT OUTER " + sql, args, nil
+	}
 	return "LEFT " + sql, args, nil
 }
 
@@ -157,13 +191,25 @@ func (ln LeftNestClause) As(alias string) LeftNestClause {
 	return ln
 }
 
+// Alias returns the alias set with As, or an empty string.
+func (ln LeftNestClause) Alias() string {
+	return ln.nestClause.alias
+}
+
+// Outer spells the clause LEFT OUTER NEST, which is equivalent to LEFT NEST.
+func (ln LeftNestClause) Outer() LeftNestClause {
+	ln.outer = true
+	return ln
+}
+
 // OnKeys sets the ON KEYS expression for the LEFT NEST clause
 func (ln LeftNestClause) OnKeys(keys string) LeftNestClause {
 	ln.nestClause = ln.nestClause.OnKeys(keys)
 	return ln
 }
 
-// On sets the ON condition for the LEFT NEST clause
+// On sets the ON condition for the LEFT NEST clause. A nil condition leaves the
+// clause unchanged.
 func (ln LeftNestClause) On(condition interface{}, args ...interface{}) LeftNestClause {
 	ln.nestClause = ln.nestClause.On(condition, args...)
 	return ln
@@ -195,12 +241,70 @@ func (lu LeftUnnestClause) As(alias string) LeftUnnestClause {
 	return lu
 }
 
-// On sets the ON condition for the LEFT UNNEST clause
+// Alias returns the alias set with As, or an empty string.
+func (lu LeftUnnestClause) Alias() string {
+	return lu.unnestClause.alias
+}
+
+// On sets the ON condition for the LEFT UNNEST clause. A nil condition leaves the
+// clause unchanged.
 func (lu LeftUnnestClause) On(condition interface{}, args ...interface{}) LeftUnnestClause {
 	lu.unnestClause = lu.unnestClause.On(condition, args...)
 	return lu
 }
 
+// AliasRef builds a reference to a field of a NEST or UNNEST alias for use in
+// projections and predicates, quoting each path segment, e.g.
+// AliasRef("i", "price") returns "i.`price`" and AliasRef("i", "dims.width")
+// returns "i.`dims`.`width`".
+func AliasRef(alias, field string) string {
+	if field == "" {
+		return alias
+	}
+
+	parts := strings.Split(field, ".")
+	quoted := make([]string, len(parts))
+	for i, p := range parts {
+		quoted[i] = fmt.Sprintf("`%s`", p)
+	}
+
+	return fmt.Sprintf("%s.%s", alias, strings.Join(quoted, "."))
+}
+
+// AnyIn renders "ANY variable IN array SATISFIES pred END", true if any
+// element of array satisfies pred. It filters on the contents of a nested
+// array, e.g. the alias of a NEST clause in the WHERE clause of the query:
+//
+//	Select("u.name").From("users u").
+//		NestClause(Nest("orders").As("o").On("o.userId = META(u).id")).
+//		Where(AnyIn("x", "o", "x.total > ?", 100))
+//
+// pred may be another AnyIn, or any N1qlizer, to filter on arrays nested in
+// the elements of array; its args are spliced in and each level is closed by
+// its own END:
+//
+//	AnyIn("v", "u.visits", AnyIn("w", "v.pages", "w = ?", "/home"))
+//
+// renders "ANY v IN u.visits SATISFIES ANY w IN v.pages SATISFIES w = ? END END".
+func AnyIn(variable, array string, pred any, args ...any) N1qlizer {
+	return anyIn{variable: variable, array: array, pred: Expr(pred, args...)}
+}
+
+type anyIn struct {
+	variable string
+	array    string
+	pred     N1qlizer
+}
+
+func (a anyIn) ToN1ql() (string, []any, error) {
+	sql, args, err := nestedToN1ql(a.pred)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("ANY %s IN %s SATISFIES %s END", a.variable, a.array, sql), args, nil
+}
+
 // SelectBuilder methods to support NEST and UNNEST
 
 // Nest adds a NEST clause to the query
@@ -213,6 +317,11 @@ func (b SelectBuilder) NestClause(nest NestClause) SelectBuilder {
 	return Append[SelectBuilder, NestClause](b, "Joins", nest)
 }
 
+// InnerNest adds an INNER NEST clause to the query
+func (b SelectBuilder) InnerNest(bucket string) SelectBuilder {
+	return b.NestClause(InnerNest(bucket))
+}
+
 // LeftNest adds a LEFT NEST clause to the query
 func (b SelectBuilder) LeftNest(bucket string) SelectBuilder {
 	return b.LeftNestClause(LeftNest(bucket))
@@ -228,6 +337,14 @@ func (b SelectBuilder) Unnest(path string) SelectBuilder {
 	return b.UnnestClause(Unnest(path))
 }
 
+// UnnestAs adds an aliased UNNEST clause to the query, rendered as
+// "UNNEST path AS alias". Later clauses may unnest the alias further:
+//
+//	.UnnestAs("o.items", "i").UnnestAs("i.tags", "t")
+func (b SelectBuilder) UnnestAs(path, alias string) SelectBuilder {
+	return b.UnnestClause(Unnest(path).As(alias))
+}
+
 // UnnestClause adds an UNNEST clause to the query
 func (b SelectBuilder) UnnestClause(unnest UnnestClause) SelectBuilder {
 	return Append[SelectBuilder, UnnestClause](b, "Joins", unnest)
@@ -238,6 +355,12 @@ func (b SelectBuilder) LeftUnnest(path string) SelectBuilder {
 	return b.LeftUnnestClause(LeftUnnest(path))
 }
 
+// LeftUnnestAs adds an aliased LEFT UNNEST clause to the query, rendered as
+// "LEFT UNNEST path AS alias".
+func (b SelectBuilder) LeftUnnestAs(path, alias string) SelectBuilder {
+	return b.LeftUnnestClause(LeftUnnest(path).As(alias))
+}
+
 // LeftUnnestClause adds a LEFT UNNEST clause to the query
 func (b SelectBuilder) LeftUnnestClause(unnest LeftUnnestClause) SelectBuilder {
 	return Append[SelectBuilder, LeftUnnestClause](b, "Joins", unnest)
@@ -0,0 +1,300 @@
+package n1qlizer
+
+import (
+	"testing"
+)
+
+// TestAliasRef tests referencing NEST and UNNEST aliases
+func TestAliasRef(t *testing.T) {
+	t.Run("Field reference", func(t *testing.T) {
+		if ref := AliasRef("i", "price"); ref != "i.`price`" {
+			t.Errorf("Expected 'i.`price`', got '%s'", ref)
+		}
+	})
+
+	t.Run("Nested field reference", func(t *testing.T) {
+		if ref := AliasRef("i", "dims.width"); ref != "i.`dims`.`width`" {
+			t.Errorf("Expected 'i.`dims`.`width`', got '%s'", ref)
+		}
+	})
+
+	t.Run("Alias only", func(t *testing.T) {
+		if ref := AliasRef("i", ""); ref != "i" {
+			t.Errorf("Expected 'i', got '%s'", ref)
+		}
+	})
+
+	t.Run("Projection from UNNEST alias", func(t *testing.T) {
+		items := Unnest("u.items").As("i")
+		if items.Alias() != "i" {
+			t.Fatalf("Expected alias 'i', got '%s'", items.Alias())
+		}
+
+		sql, _, err := Select(AliasRef(items.Alias(), "price")).
+			From("users u").
+			UnnestClause(items).
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		expected := "SELECT i.`price` FROM users u UNNEST u.items AS i"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+	})
+
+	t.Run("Aliases of other clauses", func(t *testing.T) {
+		if a := Nest("orders").As("o").Alias(); a != "o" {
+			t.Errorf("Expected NEST alias 'o', got '%s'", a)
+		}
+		if a := LeftNest("orders").As("o").Alias(); a != "o" {
+			t.Errorf("Expected LEFT NEST alias 'o', got '%s'", a)
+		}
+		if a := LeftUnnest("u.tags").As("t").Alias(); a != "t" {
+			t.Errorf("Expected LEFT UNNEST alias 't', got '%s'", a)
+		}
+	})
+}
+
+// TestUnnestAs tests aliased and chained UNNEST clauses
+func TestUnnestAs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		builder  SelectBuilder
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "UNNEST with alias",
+			builder:  Select("i.name").From("orders o").UnnestAs("o.items", "i"),
+			expected: "SELECT i.name FROM orders o UNNEST o.items AS i",
+			args:     []interface{}{},
+		},
+		{
+			name: "Chained UNNEST referencing previous alias",
+			builder: Select("o.id", "t").
+				From("orders o").
+				UnnestAs("o.items", "i").
+				UnnestAs("i.tags", "t").
+				Where("t = ?", "sale"),
+			expected: "SELECT o.id, t FROM orders o UNNEST o.items AS i UNNEST i.tags AS t WHERE t = ?",
+			args:     []interface{}{"sale"},
+		},
+		{
+			name: "UNNEST followed by LEFT UNNEST",
+			builder: Select("o.id").
+				From("orders o").
+				UnnestAs("o.items", "i").
+				LeftUnnestAs("i.discounts", "d"),
+			expected: "SELECT o.id FROM orders o UNNEST o.items AS i LEFT UNNEST i.discounts AS d",
+			args:     []interface{}{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+
+			if len(args) != len(tc.args) {
+				t.Fatalf("Wrong number of args: Expected %d, got %d", len(tc.args), len(args))
+			}
+
+			for i, arg := range args {
+				if arg != tc.args[i] {
+					t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, tc.args[i], arg)
+				}
+			}
+		})
+	}
+}
+
+// TestNestOnWithArgs tests ANSI NEST with a bound ON predicate and a filter
+// on the nested array
+func TestNestOnWithArgs(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Select("u.name", "o").
+		From("users u").
+		NestClause(Nest("orders").As("o").On("o.user = u.id AND o.status = ?", "paid")).
+		Where("u.active = ?", true).
+		Where(AnyIn("x", "o", "x.total > ?", 100)).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT u.name, o FROM users u NEST orders AS o ON o.user = u.id AND o.status = $1 " +
+		"WHERE u.active = $2 AND ANY x IN o SATISFIES x.total > $3 END"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"paid", true, 100}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+
+	sql, args, err = sb.Select("*").
+		From("users u").
+		LeftNestClause(LeftNest("orders").As("o").On("o.user = u.id")).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected = "SELECT * FROM users u LEFT NEST orders AS o ON o.user = u.id"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+	if len(args) != 0 {
+		t.Errorf("Expected no args, got %v", args)
+	}
+}
+
+// TestNestJoinKeywords tests the INNER NEST and LEFT OUTER NEST spellings
+func TestNestJoinKeywords(t *testing.T) {
+	tests := []struct {
+		name         string
+		builder      SelectBuilder
+		expected     string
+		expectedArgs []interface{}
+	}{
+		{
+			"INNER NEST ON KEYS",
+			Select("*").From("users u").NestClause(InnerNest("orders").As("o").OnKeys("u.orderIds")),
+			"SELECT * FROM users u INNER NEST orders AS o ON KEYS u.orderIds",
+			nil,
+		},
+		{
+			"INNER NEST ON",
+			Select("*").From("users u").NestClause(InnerNest("orders").As("o").On("o.userId = META(u).id AND o.total > ?", 10)),
+			"SELECT * FROM users u INNER NEST orders AS o ON o.userId = META(u).id AND o.total > ?",
+			[]interface{}{10},
+		},
+		{
+			"INNER NEST shorthand",
+			Select("*").From("users u").InnerNest("orders"),
+			"SELECT * FROM users u INNER NEST orders",
+			nil,
+		},
+		{
+			"LEFT OUTER NEST ON KEYS",
+			Select("*").From("users u").LeftNestClause(LeftNest("orders").Outer().As("o").OnKeys("u.orderIds")),
+			"SELECT * FROM users u LEFT OUTER NEST orders AS o ON KEYS u.orderIds",
+			nil,
+		},
+		{
+			"LEFT OUTER NEST ON",
+			Select("*").From("users u").LeftNestClause(LeftNest("orders").As("o").On("o.userId = META(u).id").Outer()),
+			"SELECT * FROM users u LEFT OUTER NEST orders AS o ON o.userId = META(u).id",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+// TestAnyInNested tests collection predicates nested in each other
+func TestAnyInNested(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	tests := []struct {
+		name         string
+		pred         N1qlizer
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name:         "Two levels",
+			pred:         AnyIn("v", "u.visits", AnyIn("w", "v.pages", "w = ?", "/home")),
+			expected:     "ANY v IN u.visits SATISFIES ANY w IN v.pages SATISFIES w = $2 END END",
+			expectedArgs: []any{"/home"},
+		},
+		{
+			name: "Three levels with args at each level",
+			pred: AnyIn("o", "u.orders", And{
+				Expr("o.status = ?", "paid"),
+				AnyIn("l", "o.lines", And{
+					Expr("l.qty > ?", 1),
+					AnyIn("t", "l.tags", "t = ?", "gift"),
+				}),
+			}),
+			expected: "ANY o IN u.orders SATISFIES (o.status = $2 AND " +
+				"ANY l IN o.lines SATISFIES (l.qty > $3 AND ANY t IN l.tags SATISFIES t = $4 END) END) END",
+			expectedArgs: []any{"paid", 1, "gift"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := sb.Select("*").From("users u").Where("u.active = ?", true).Where(tt.pred).ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			expected := "SELECT * FROM users u WHERE u.active = $1 AND " + tt.expected
+			if sql != expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+			}
+
+			expectedArgs := append([]any{true}, tt.expectedArgs...)
+			if !argsEqual(expectedArgs, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+			}
+		})
+	}
+}
+
+// TestNestOnNil tests that a nil ON condition leaves NEST and UNNEST clauses
+// unchanged
+func TestNestOnNil(t *testing.T) {
+	tests := []struct {
+		name     string
+		clause   N1qlizer
+		expected string
+	}{
+		{"NEST", Nest("orders").As("o").On(nil), "NEST orders AS o"},
+		{"NEST keeps condition", Nest("orders").As("o").On("o.user = u.id").On(nil), "NEST orders AS o ON o.user = u.id"},
+		{"UNNEST", Unnest("u.items").As("i").On(nil), "UNNEST u.items AS i"},
+		{"LEFT NEST", LeftNest("orders").As("o").On(nil), "LEFT NEST orders AS o"},
+		{"LEFT UNNEST", LeftUnnest("u.items").As("i").On(nil), "LEFT UNNEST u.items AS i"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.clause.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build clause: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+			if len(args) != 0 {
+				t.Errorf("Expected no args, got %v", args)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package n1qlizer
+
+import (
+	"context"
+	"fmt"
+)
+
+// QueryOptions are request-level settings sent to Couchbase along with a
+// query, rather than rendered into its N1QL.
+type QueryOptions struct {
+	// ReadOnly asks the query service to reject statements that modify data.
+	ReadOnly bool
+	// Metrics requests the metrics section in the query response.
+	Metrics bool
+	// Pretty requests an indented response from the query service.
+	Pretty bool
+	// Profile requests profiling information: "off", "phases" or "timings".
+	Profile string
+}
+
+// QueryExecutorOptions is implemented by runners that can send QueryOptions
+// with a query, e.g. by mapping them to the Couchbase SDK's query options.
+type QueryExecutorOptions interface {
+	ExecuteOptions(ctx context.Context, opts QueryOptions, query string, args ...any) (QueryResult, error)
+}
+
+// ExecuteOptionsWith executes the given N1qlizer with opts if db implements
+// QueryExecutorOptions. Other runners execute the query as ExecuteWith does
+// and the options are ignored.
+func ExecuteOptionsWith(db QueryExecutor, opts QueryOptions, n N1qlizer) (res QueryResult, err error) {
+	runner, ok := db.(QueryExecutorOptions)
+	if !ok {
+		return ExecuteWith(db, n)
+	}
+
+	query, args, err := n.ToN1ql()
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.ExecuteOptions(context.Background(), opts, query, args...)
+}
+
+// ExecuteContextOptionsWith executes the given N1qlizer with context and opts
+// if db implements QueryExecutorOptions. Other runners execute the query as
+// ExecuteContextWith does and the options are ignored.
+func ExecuteContextOptionsWith(ctx context.Context, db QueryExecutorContext, opts QueryOptions, n N1qlizer) (res QueryResult, err error) {
+	runner, ok := db.(QueryExecutorOptions)
+	if !ok {
+		return ExecuteContextWith(ctx, db, n)
+	}
+
+	query, args, err := n.ToN1ql()
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.ExecuteOptions(ctx, opts, query, args...)
+}
+
+// QueryResultMetadata is implemented by QueryResults that expose the
+// metadata returned by the query service along with the rows.
+type QueryResultMetadata interface {
+	// Profile returns the profile section of the response, present when the
+	// query was executed with QueryOptions.Profile set to "phases" or
+	// "timings".
+	Profile() (map[string]any, error)
+}
+
+// ProfileNotAvailable is returned by ResultProfile if the result does not
+// carry profiling information.
+var ProfileNotAvailable = fmt.Errorf("query result has no profile; set QueryOptions.Profile and use a runner that returns it")
+
+// ResultProfile returns the profile section of res if it implements
+// QueryResultMetadata, and ProfileNotAvailable otherwise.
+func ResultProfile(res QueryResult) (map[string]any, error) {
+	md, ok := res.(QueryResultMetadata)
+	if !ok {
+		return nil, ProfileNotAvailable
+	}
+	return md.Profile()
+}
@@ -0,0 +1,111 @@
+package n1qlizer
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueryOptions(t *testing.T) {
+	t.Run("Options-aware runner", func(t *testing.T) {
+		runner := NewMockRunner()
+		runner.ExpectQuery("UPDATE users SET name = ?", "John")
+
+		opts := QueryOptions{Metrics: true, Profile: "timings"}
+		_, err := Update("users").Set("name", "John").WithOptions(opts).RunWith(runner).Execute()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		calls := runner.Calls()
+		if len(calls) != 1 {
+			t.Fatalf("Expected 1 call, got %d", len(calls))
+		}
+		if calls[0].Options != opts {
+			t.Errorf("Expected options %+v, got %+v", opts, calls[0].Options)
+		}
+	})
+
+	t.Run("ReadOnly keeps other options", func(t *testing.T) {
+		runner := NewMockRunner()
+		runner.ExpectQuery("SELECT * FROM users")
+
+		_, err := Select("*").From("users").
+			WithOptions(QueryOptions{Pretty: true}).
+			ReadOnly().
+			RunWithContext(runner).
+			ExecuteContext(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		expected := QueryOptions{ReadOnly: true, Pretty: true}
+		if got := runner.Calls()[0].Options; got != expected {
+			t.Errorf("Expected options %+v, got %+v", expected, got)
+		}
+	})
+
+	t.Run("Runner without options support", func(t *testing.T) {
+		runner := NewDryRunRunner()
+
+		_, err := Select("*").From("users").Where("id = ?", 1).ReadOnly().RunWith(runner).Execute()
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if runner.LastQuery() != "SELECT * FROM users WHERE id = ?" {
+			t.Errorf("Unexpected query: %s", runner.LastQuery())
+		}
+
+		_, err = Delete("users").ByKey("u1").
+			WithOptions(QueryOptions{Metrics: true}).
+			RunWithContext(runner).
+			ExecuteContext(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to execute query: %v", err)
+		}
+
+		if runner.Count() != 2 {
+			t.Errorf("Expected 2 queries, got %d", runner.Count())
+		}
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := Select("*").From("users").RunWithContext(NewMockRunner()).ExecuteContext(ctx)
+		if err == nil {
+			t.Error("Expected error for cancelled context, got nil")
+		}
+	})
+}
+
+func TestResultProfile(t *testing.T) {
+	profile := map[string]any{"phaseTimes": map[string]any{"fetch": "1.2ms"}}
+	runner := NewMockRunner()
+	runner.Enqueue(NewMockResult().WithProfile(profile))
+
+	res, err := Select("*").From("users").
+		WithOptions(QueryOptions{Profile: "timings"}).
+		RunWith(runner).
+		Execute()
+	if err != nil {
+		t.Fatalf("Failed to execute query: %v", err)
+	}
+
+	got, err := ResultProfile(res)
+	if err != nil {
+		t.Fatalf("Failed to read profile: %v", err)
+	}
+	if got["phaseTimes"].(map[string]any)["fetch"] != "1.2ms" {
+		t.Errorf("Unexpected profile: %v", got)
+	}
+
+	if _, err := ResultProfile(NewMockResult()); err != ProfileNotAvailable {
+		t.Errorf("Expected ProfileNotAvailable, got %v", err)
+	}
+
+	if _, err := ResultProfile(emptyResult{}); err != ProfileNotAvailable {
+		t.Errorf("Expected ProfileNotAvailable for result without metadata, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package n1qlizer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// paramOperators maps the operator suffixes of filter params to the
+// expression they build.
+var paramOperators = map[string]func(field string, value any) N1qlizer{
+	"":    func(field string, value any) N1qlizer { return Eq{field: value} },
+	"ne":  func(field string, value any) N1qlizer { return NotEq{field: value} },
+	"gt":  func(field string, value any) N1qlizer { return Gt{field: value} },
+	"gte": func(field string, value any) N1qlizer { return Gte{field: value} },
+	"lt":  func(field string, value any) N1qlizer { return Lt{field: value} },
+	"lte": func(field string, value any) N1qlizer { return Lte{field: value} },
+}
+
+// WhereFromParams builds a WHERE expression from request filter params, such
+// as the query string of a REST API, allowing only the params listed in
+// allowed. Values are always bound as args, never inlined.
+//
+// allowed maps param names to the document field they filter, optionally
+// followed by the type the value is converted to: "field:string" (the
+// default), "field:int", "field:float" or "field:bool". Field names may
+// contain colons inside backticks, e.g. "u.`a:b`:int".
+//
+// A param name may carry an operator suffix: "age__gt", "age__gte",
+// "age__lt", "age__lte" and "age__ne". Without a suffix the param tests for
+// equality. For example
+//
+//	WhereFromParams(
+//		map[string]string{"status": "active", "age__gte": "18"},
+//		map[string]string{"status": "u.status", "age": "u.age:int"},
+//	)
+//
+// builds "(u.age >= ? AND u.status = ?)" with args [18 active]. Conditions are
+// combined with AND in sorted param order. An unknown param, an unknown
+// operator or a value that cannot be converted is an error.
+func WhereFromParams(params map[string]string, allowed map[string]string) (N1qlizer, error) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	conditions := make(And, 0, len(names))
+	for _, name := range names {
+		param, op := name, ""
+		if i := strings.LastIndex(name, "__"); i >= 0 {
+			param, op = name[:i], name[i+2:]
+		}
+
+		spec, ok := allowed[param]
+		if !ok {
+			return nil, fmt.Errorf("filter param %q is not allowed", name)
+		}
+
+		build, ok := paramOperators[op]
+		if !ok {
+			return nil, fmt.Errorf("filter param %q has unknown operator %q", name, op)
+		}
+
+		field, typ := splitParamSpec(spec)
+		value, err := convertParam(params[name], typ)
+		if err != nil {
+			return nil, fmt.Errorf("filter param %q: %v", name, err)
+		}
+
+		conditions = append(conditions, build(field, value))
+	}
+
+	return conditions, nil
+}
+
+// paramTypes are the types a filter param value can be converted to.
+var paramTypes = []string{"string", "int", "float", "bool"}
+
+// splitParamSpec splits an allowed param spec into its field and the type of
+// its value. Only a trailing ":string", ":int", ":float" or ":bool" is taken
+// as the type, so colons inside backticked field names are kept. Any other
+// ":" outside backticks is taken to start an unknown type, which
+// convertParam rejects.
+func splitParamSpec(spec string) (field, typ string) {
+	for _, t := range paramTypes {
+		if strings.HasSuffix(spec, ":"+t) {
+			return spec[:len(spec)-len(t)-1], t
+		}
+	}
+	if hasUnquoted(spec, ':') {
+		i := strings.LastIndex(spec, ":")
+		return spec[:i], spec[i+1:]
+	}
+	return spec, "string"
+}
+
+// convertParam converts a filter param value to the given type.
+func convertParam(value, typ string) (any, error) {
+	switch typ {
+	case "string":
+		return value, nil
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	default:
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+}
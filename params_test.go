@@ -0,0 +1,109 @@
+package n1qlizer
+
+import (
+	"testing"
+)
+
+// TestWhereFromParams tests building WHERE conditions from filter params
+func TestWhereFromParams(t *testing.T) {
+	allowed := map[string]string{
+		"status": "u.status",
+		"age":    "u.age:int",
+		"score":  "u.score:float",
+		"admin":  "u.admin:bool",
+		"ab":     "u.`a:b`",
+		"abn":    "u.`a:b`:int",
+	}
+
+	testCases := []struct {
+		name     string
+		params   map[string]string
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "Equality",
+			params:   map[string]string{"status": "active"},
+			expected: "u.status = ?",
+			args:     []interface{}{"active"},
+		},
+		{
+			name:     "Operators and types",
+			params:   map[string]string{"age__gte": "18", "age__lt": "65", "score__gt": "4.5", "admin": "true", "status__ne": "banned"},
+			expected: "(u.admin = ? AND u.age >= ? AND u.age < ? AND u.score > ? AND u.status <> ?)",
+			args:     []interface{}{true, int64(18), int64(65), 4.5, "banned"},
+		},
+		{
+			name:     "Colon in backticked field",
+			params:   map[string]string{"ab": "x", "abn": "3"},
+			expected: "(u.`a:b` = ? AND u.`a:b` = ?)",
+			args:     []interface{}{"x", int64(3)},
+		},
+		{
+			name:     "No params",
+			params:   map[string]string{},
+			expected: "",
+			args:     nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			where, err := WhereFromParams(tc.params, allowed)
+			if err != nil {
+				t.Fatalf("Failed to build conditions: %v", err)
+			}
+
+			sql, args, err := where.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build expression: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+
+			if !argsEqual(tc.args, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tc.args, args)
+			}
+		})
+	}
+
+	t.Run("In a query", func(t *testing.T) {
+		where, err := WhereFromParams(map[string]string{"status": "active"}, allowed)
+		if err != nil {
+			t.Fatalf("Failed to build conditions: %v", err)
+		}
+
+		sql, _, err := Select("*").From("users u").Where(where).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		if sql != "SELECT * FROM users u WHERE u.status = ?" {
+			t.Errorf("Wrong SQL: %s", sql)
+		}
+	})
+
+	errorCases := []struct {
+		name   string
+		params map[string]string
+	}{
+		{"Unknown param", map[string]string{"password": "x"}},
+		{"Unknown operator", map[string]string{"age__like": "1"}},
+		{"Invalid int", map[string]string{"age": "old"}},
+		{"Invalid bool", map[string]string{"admin": "maybe"}},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := WhereFromParams(tc.params, allowed); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+
+	if _, err := WhereFromParams(map[string]string{"x": "1"}, map[string]string{"x": "u.x:date"}); err == nil {
+		t.Error("Expected error for unknown type, got nil")
+	}
+}
@@ -3,26 +3,47 @@ package n1qlizer
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 )
 
 // selectData stores the state of a SELECT query as it is built
 type selectData struct {
-	PlaceholderFormat PlaceholderFormat
-	RunWith           QueryRunner
-	Prefixes          []N1qlizer
-	Options           []string
-	Columns           []N1qlizer
-	From              N1qlizer
-	Joins             []N1qlizer
-	WhereParts        []N1qlizer
-	GroupBys          []string
-	HavingParts       []N1qlizer
-	OrderByParts      []N1qlizer
-	Limit             string
-	Offset            string
-	Suffixes          []N1qlizer
-	UseKeys           string
+	PlaceholderFormat       PlaceholderFormat
+	PlaceholderOffset       int
+	MaxQueryLength          int
+	FormatTimes             bool
+	Strict                  bool
+	StrictPaging            bool
+	ExpandDefaultCollection bool
+	Namespace               string
+	RunWith                 QueryRunner
+	ExtraArgs               []any
+	QueryOptions            QueryOptions
+	Prefixes                []N1qlizer
+	CTEs                    []N1qlizer
+	Hints                   []string
+	Options                 []string
+	Raw                     bool
+	Columns                 []N1qlizer
+	From                    N1qlizer
+	Joins                   []N1qlizer
+	WhereParts              []N1qlizer
+	GroupBys                []N1qlizer
+	HavingParts             []N1qlizer
+	OrderByParts            []N1qlizer
+	Limit                   string
+	LimitExpr               N1qlizer
+	DefaultLimit            string
+	Offset                  string
+	OffsetExpr              N1qlizer
+	Suffixes                []N1qlizer
+	UseKeys                 N1qlizer
+	UseIndex                N1qlizer
+	SetOperations           []N1qlizer
+	DedupeWhere             bool
+	AutoGroup               bool
+	Pretty                  bool
 }
 
 func (d *selectData) ToN1ql() (sqlStr string, args []any, err error) {
@@ -31,7 +52,14 @@ func (d *selectData) ToN1ql() (sqlStr string, args []any, err error) {
 		return
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sqlStr)
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, sqlStr, d.PlaceholderOffset)
+	if err != nil {
+		return
+	}
+
+	args = append(args, d.ExtraArgs...)
+
+	err = checkQueryLength(sqlStr, d.MaxQueryLength)
 	return
 }
 
@@ -41,6 +69,55 @@ func (d *selectData) toN1qlRaw() (sqlStr string, args []any, err error) {
 		return
 	}
 
+	// clauseSep separates the major clauses, which start on their own line
+	// when pretty-printing.
+	clauseSep, andSep := " ", " AND "
+	if d.Pretty {
+		clauseSep, andSep = "\n", "\n  AND "
+	}
+
+	groupBys := d.GroupBys
+	if d.AutoGroup {
+		if groupBys, err = d.autoGroupBys(); err != nil {
+			return
+		}
+	}
+
+	if len(d.HavingParts) > 0 && len(groupBys) == 0 && !d.hasAggregateColumn() {
+		err = fmt.Errorf("select statements with HAVING must have a GROUP BY or an aggregate column")
+		return
+	}
+
+	if d.Raw {
+		if len(d.Columns) != 1 {
+			err = fmt.Errorf("select raw statements must have exactly one result column")
+			return
+		}
+
+		for _, option := range d.Options {
+			if strings.EqualFold(option, "DISTINCT") {
+				err = fmt.Errorf("select raw statements cannot be combined with DISTINCT")
+				return
+			}
+		}
+	}
+
+	if d.Strict {
+		if err = checkStrict(d.strictParts()...); err != nil {
+			return
+		}
+	}
+
+	if err = checkOrderBy(d.OrderByParts...); err != nil {
+		return
+	}
+
+	if d.StrictPaging && (len(d.Offset) > 0 || d.OffsetExpr != nil) &&
+		len(d.Limit) == 0 && d.LimitExpr == nil && len(d.DefaultLimit) == 0 {
+		err = fmt.Errorf("select statements with OFFSET must have a LIMIT")
+		return
+	}
+
 	sql := &bytes.Buffer{}
 
 	if len(d.Prefixes) > 0 {
@@ -52,13 +129,38 @@ func (d *selectData) toN1qlRaw() (sqlStr string, args []any, err error) {
 		sql.WriteString(" ")
 	}
 
+	if len(d.CTEs) > 0 {
+		sql.WriteString("WITH ")
+		for _, c := range d.CTEs {
+			if c.(cte).recursive != nil {
+				sql.WriteString("RECURSIVE ")
+				break
+			}
+		}
+
+		args, err = buildClauses(d.CTEs, sql, ", ", args)
+		if err != nil {
+			return
+		}
+
+		sql.WriteString(clauseSep)
+	}
+
 	sql.WriteString("SELECT ")
 
+	if err = writeHints(sql, d.Hints); err != nil {
+		return
+	}
+
 	if len(d.Options) > 0 {
 		sql.WriteString(strings.Join(d.Options, " "))
 		sql.WriteString(" ")
 	}
 
+	if d.Raw {
+		sql.WriteString("RAW ")
+	}
+
 	if len(d.Columns) > 0 {
 		args, err = buildClauses(d.Columns, sql, ", ", args)
 		if err != nil {
@@ -67,77 +169,176 @@ func (d *selectData) toN1qlRaw() (sqlStr string, args []any, err error) {
 	}
 
 	if d.From != nil {
-		sql.WriteString(" FROM ")
-		args, err = buildClauses([]N1qlizer{d.From}, sql, "", args)
+		sql.WriteString(clauseSep + "FROM ")
+		from := d.From
+		if e, ok := from.(expr); ok && (d.ExpandDefaultCollection || len(d.Namespace) > 0) {
+			keyspaces := e.sql
+			if d.ExpandDefaultCollection {
+				keyspaces = qualifyKeyspaces(keyspaces)
+			}
+			from = expr{sql: namespaceKeyspaces(keyspaces, d.Namespace), args: e.args}
+		}
+		args, err = buildClauses([]N1qlizer{from}, sql, "", args)
 		if err != nil {
 			return
 		}
 
-		if d.UseKeys != "" {
+		if d.UseKeys != nil {
 			sql.WriteString(" USE KEYS ")
-			sql.WriteString(d.UseKeys)
+			args, err = buildClauses([]N1qlizer{d.UseKeys}, sql, "", args)
+			if err != nil {
+				return
+			}
+		}
+
+		if d.UseIndex != nil {
+			sql.WriteString(" ")
+			args, err = buildClauses([]N1qlizer{d.UseIndex}, sql, "", args)
+			if err != nil {
+				return
+			}
 		}
 	}
 
 	if len(d.Joins) > 0 {
-		sql.WriteString(" ")
-		args, err = buildClauses(d.Joins, sql, " ", args)
+		sql.WriteString(clauseSep)
+		args, err = buildClauses(d.Joins, sql, clauseSep, args)
+		if err != nil {
+			return
+		}
+	}
+
+	whereParts := d.WhereParts
+	if d.DedupeWhere {
+		whereParts, err = dedupeClauses(whereParts)
 		if err != nil {
 			return
 		}
 	}
 
-	if len(d.WhereParts) > 0 {
-		sql.WriteString(" WHERE ")
-		args, err = buildClauses(d.WhereParts, sql, " AND ", args)
+	if len(whereParts) > 0 {
+		where := &bytes.Buffer{}
+		args, err = buildClauses(whereParts, where, andSep, args)
 		if err != nil {
 			return
 		}
+		if where.Len() > 0 {
+			sql.WriteString(clauseSep + "WHERE ")
+			sql.Write(where.Bytes())
+		}
 	}
 
-	if len(d.GroupBys) > 0 {
-		sql.WriteString(" GROUP BY ")
-		sql.WriteString(strings.Join(d.GroupBys, ", "))
+	if len(groupBys) > 0 {
+		sql.WriteString(clauseSep + "GROUP BY ")
+		args, err = buildClauses(groupBys, sql, ", ", args)
+		if err != nil {
+			return
+		}
 	}
 
 	if len(d.HavingParts) > 0 {
-		sql.WriteString(" HAVING ")
-		args, err = buildClauses(d.HavingParts, sql, " AND ", args)
+		// Parts rendering to nothing, such as an empty And, are skipped, and
+		// so is the whole clause if none is left.
+		having := &bytes.Buffer{}
+		args, err = buildClauses(d.HavingParts, having, andSep, args)
+		if err != nil {
+			return
+		}
+		if having.Len() > 0 {
+			sql.WriteString(clauseSep + "HAVING ")
+			sql.Write(having.Bytes())
+		}
+	}
+
+	// Set operations come before ORDER BY, LIMIT and OFFSET, which apply to
+	// the combined result.
+	if len(d.SetOperations) > 0 {
+		sql.WriteString(clauseSep)
+		args, err = buildClauses(d.SetOperations, sql, clauseSep, args)
 		if err != nil {
 			return
 		}
 	}
 
 	if len(d.OrderByParts) > 0 {
-		sql.WriteString(" ORDER BY ")
+		sql.WriteString(clauseSep + "ORDER BY ")
 		args, err = buildClauses(d.OrderByParts, sql, ", ", args)
 		if err != nil {
 			return
 		}
 	}
 
-	if len(d.Limit) > 0 {
-		sql.WriteString(" LIMIT ")
+	if d.LimitExpr != nil {
+		sql.WriteString(clauseSep + "LIMIT ")
+		args, err = buildClauses([]N1qlizer{d.LimitExpr}, sql, "", args)
+		if err != nil {
+			return
+		}
+	} else if len(d.Limit) > 0 {
+		sql.WriteString(clauseSep + "LIMIT ")
 		sql.WriteString(d.Limit)
+	} else if len(d.DefaultLimit) > 0 {
+		sql.WriteString(clauseSep + "LIMIT ")
+		sql.WriteString(d.DefaultLimit)
 	}
 
-	if len(d.Offset) > 0 {
-		sql.WriteString(" OFFSET ")
+	if d.OffsetExpr != nil {
+		sql.WriteString(clauseSep + "OFFSET ")
+		args, err = buildClauses([]N1qlizer{d.OffsetExpr}, sql, "", args)
+		if err != nil {
+			return
+		}
+	} else if len(d.Offset) > 0 {
+		sql.WriteString(clauseSep + "OFFSET ")
 		sql.WriteString(d.Offset)
 	}
 
 	if len(d.Suffixes) > 0 {
-		sql.WriteString(" ")
-		args, err = buildClauses(d.Suffixes, sql, " ", args)
+		sql.WriteString(clauseSep)
+		args, err = buildClauses(d.Suffixes, sql, clauseSep, args)
 		if err != nil {
 			return
 		}
 	}
 
+	if d.FormatTimes {
+		args = formatTimeArgs(args)
+	}
+
 	sqlStr = sql.String()
 	return
 }
 
+// subquery embeds a query in parentheses within another query.
+type subquery struct {
+	query N1qlizer
+}
+
+func (s subquery) ToN1ql() (string, []any, error) {
+	sql, args, err := nestedToN1ql(s.query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("(%s)", sql), args, nil
+}
+
+// setOperation combines the result of a SELECT with another SELECT using a
+// set operator such as UNION, INTERSECT or EXCEPT.
+type setOperation struct {
+	operator string
+	query    N1qlizer
+}
+
+func (s setOperation) ToN1ql() (string, []any, error) {
+	sql, args, err := nestedToN1ql(s.query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("%s %s", s.operator, sql), args, nil
+}
+
 // SelectBuilder builds SELECT statements.
 type SelectBuilder Builder
 
@@ -151,18 +352,132 @@ func (b SelectBuilder) PlaceholderFormat(f PlaceholderFormat) SelectBuilder {
 	return Set[SelectBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
 }
 
+// StartPlaceholdersAt makes numbered placeholder formats (e.g. Dollar) start
+// numbering at n+1, for queries appended to N1QL that already uses $1..$n.
+// Formats without numbered placeholders ignore it.
+func (b SelectBuilder) StartPlaceholdersAt(n int) SelectBuilder {
+	return Set[SelectBuilder, int](b, "PlaceholderOffset", n)
+}
+
+func (b SelectBuilder) withPlaceholderOffset(n int) N1qlizer {
+	return b.StartPlaceholdersAt(n)
+}
+
+// MaxQueryLength makes ToN1ql fail if the generated N1QL is longer than n
+// bytes, catching runaway dynamic queries. Zero, the default, means unlimited.
+func (b SelectBuilder) MaxQueryLength(n int) SelectBuilder {
+	return Set[SelectBuilder, int](b, "MaxQueryLength", n)
+}
+
+// FormatTimes makes the query bind time.Time and *time.Time args as RFC 3339
+// strings, the format N1QL date functions and comparisons expect. Zero times
+// and nil pointers are bound as NULL.
+func (b SelectBuilder) FormatTimes() SelectBuilder {
+	return Set[SelectBuilder, bool](b, "FormatTimes", true)
+}
+
+// StrictMode makes ToN1ql fail if the N1QL of an expression passed as a
+// string looks like it has unbound user input concatenated into it: a ";", a
+// "--" or "/*" comment marker, or an unterminated quote outside of quoted
+// literals. It is a defense-in-depth check; values should still be bound
+// with placeholders.
+func (b SelectBuilder) StrictMode() SelectBuilder {
+	return Set[SelectBuilder, bool](b, "Strict", true)
+}
+
+// StrictPaging makes ToN1ql fail if the query has an OFFSET but no LIMIT,
+// which is usually a paging bug and is rejected by some N1QL versions.
+func (b SelectBuilder) StrictPaging() SelectBuilder {
+	return Set[SelectBuilder, bool](b, "StrictPaging", true)
+}
+
+// ExtraArgs appends args to the end of the args returned by ToN1ql and Args
+// without adding placeholders, for driver integrations that take trailing
+// parameters after the query's own. The query's placeholders and args no
+// longer line up one to one, so only pass the result to a runner that
+// expects the extra args.
+func (b SelectBuilder) ExtraArgs(args ...any) SelectBuilder {
+	return Extend[SelectBuilder, any](b, "ExtraArgs", args)
+}
+
 // RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
 func (b SelectBuilder) RunWith(runner QueryRunner) SelectBuilder {
 	return Set[SelectBuilder, QueryRunner](b, "RunWith", runner)
 }
 
+// WithOptions sets the QueryOptions sent with the query when it is executed,
+// replacing any set before. Runners that do not implement
+// QueryExecutorOptions ignore them.
+func (b SelectBuilder) WithOptions(opts QueryOptions) SelectBuilder {
+	return Set[SelectBuilder, QueryOptions](b, "QueryOptions", opts)
+}
+
+// With adds a common table expression to the query, rendered as
+// "WITH name AS (query)" before SELECT. Later CTEs and the query itself can
+// reference name; CTEs are rendered in the order they are added.
+func (b SelectBuilder) With(name string, query N1qlizer) SelectBuilder {
+	return Append[SelectBuilder, N1qlizer](b, "CTEs", cte{name: name, query: query})
+}
+
+// WithRecursive adds a recursive common table expression to the query,
+// rendered as "WITH RECURSIVE name AS (anchor UNION recursive)", where
+// recursive references name, e.g. to walk an org chart:
+//
+//	.WithRecursive("chain",
+//		Select("e.id", "e.managerId").From("employees e").Where("e.id = ?", id),
+//		Select("e.id", "e.managerId").From("employees e").Join("chain c ON e.id = c.managerId"))
+func (b SelectBuilder) WithRecursive(name string, anchor, recursive N1qlizer) SelectBuilder {
+	return Append[SelectBuilder, N1qlizer](b, "CTEs", cte{name: name, query: anchor, recursive: recursive})
+}
+
+// CountQuery returns a query counting the rows the query matches, for
+// paginated APIs that need the total next to a page of results. It keeps the
+// query's FROM, JOIN, USE KEYS and WHERE clauses, with their args, replaces
+// the projection with COUNT(*) and drops ORDER BY, LIMIT and OFFSET.
+//
+// Queries whose rows are not one per match of the WHERE clause, i.e. using
+// GROUP BY, HAVING, DISTINCT, RAW or set operations, are counted with a
+// subquery instead: "SELECT COUNT(*) FROM (SELECT ...) AS t".
+func (b SelectBuilder) CountQuery() SelectBuilder {
+	page := b
+	for _, field := range []string{"OrderByParts", "Limit", "LimitExpr", "DefaultLimit", "Offset", "OffsetExpr"} {
+		page = Remove(page, field)
+	}
+
+	data := GetStruct(page).(selectData)
+	count := []N1qlizer{newPart("COUNT(*)")}
+	if len(data.GroupBys) == 0 && len(data.HavingParts) == 0 && len(data.SetOperations) == 0 &&
+		len(data.Options) == 0 && !data.Raw && !data.AutoGroup {
+		return Set[SelectBuilder, []N1qlizer](page, "Columns", count)
+	}
+
+	inner := Remove(Remove(page, "Prefixes"), "Suffixes")
+
+	outer := Set[SelectBuilder, []N1qlizer](page, "Columns", count)
+	for _, field := range []string{"CTEs", "Hints", "Options", "Raw", "Joins", "WhereParts", "GroupBys",
+		"HavingParts", "SetOperations", "UseKeys", "UseIndex", "DedupeWhere", "AutoGroup"} {
+		outer = Remove(outer, field)
+	}
+	return outer.FromSelect(inner, "t")
+}
+
+// ReadOnly marks the query as read-only in its QueryOptions, so that a
+// runner implementing QueryExecutorOptions has the query service reject it
+// if it modifies data.
+func (b SelectBuilder) ReadOnly() SelectBuilder {
+	data := GetStruct(b).(selectData)
+	opts := data.QueryOptions
+	opts.ReadOnly = true
+	return b.WithOptions(opts)
+}
+
 // Execute builds and executes the query.
 func (b SelectBuilder) Execute() (QueryResult, error) {
 	data := GetStruct(b).(selectData)
 	if data.RunWith == nil {
 		return nil, RunnerNotSet
 	}
-	return ExecuteWith(data.RunWith, b)
+	return ExecuteOptionsWith(data.RunWith, data.QueryOptions, b)
 }
 
 // ToN1ql builds the query into a N1QL string and bound args.
@@ -171,12 +486,35 @@ func (b SelectBuilder) ToN1ql() (string, []any, error) {
 	return data.ToN1ql()
 }
 
+// ToN1qlNamed builds the query into a N1QL string using named parameters
+// ($p1, $p2, ...) and returns the bound args keyed by parameter name
+// (p1, p2, ...).
+func (b SelectBuilder) ToN1qlNamed() (string, map[string]any, error) {
+	return toN1qlNamed(b.PlaceholderFormat(Named))
+}
+
+// ToN1qlPretty builds the query like ToN1ql, but starts each major clause
+// (FROM, WHERE, GROUP BY, ...) on its own line, for logging and debugging.
+// The args are the same as those returned by ToN1ql.
+func (b SelectBuilder) ToN1qlPretty() (string, []any, error) {
+	data := GetStruct(b).(selectData)
+	data.Pretty = true
+	return data.ToN1ql()
+}
+
 // toN1qlRaw is used to generate N1QL for embedded usage in other queries.
 func (b SelectBuilder) toN1qlRaw() (string, []any, error) {
 	data := GetStruct(b).(selectData)
 	return data.toN1qlRaw()
 }
 
+// AsExpr wraps the query in parentheses for use as a scalar subquery, e.g. in
+// UpdateBuilder.Set, SelectBuilder.Column or as a value of Eq. Placeholders
+// are numbered by the enclosing query.
+func (b SelectBuilder) AsExpr() N1qlizer {
+	return subquery{query: b}
+}
+
 // MustN1ql builds the query into a N1QL string and bound args.
 //
 // MustN1ql panics if there are any errors.
@@ -188,6 +526,31 @@ func (b SelectBuilder) MustN1ql() (string, []any) {
 	return sql, args
 }
 
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(SelectBuilder) SelectBuilder.
+func (b SelectBuilder) Apply(fns ...func(SelectBuilder) SelectBuilder) SelectBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b SelectBuilder) ApplyIf(cond bool, fn func(SelectBuilder) SelectBuilder) SelectBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Args returns the args the query binds, without finalizing its N1QL.
+func (b SelectBuilder) Args() ([]any, error) {
+	data := GetStruct(b).(selectData)
+	_, args, err := data.toN1qlRaw()
+	return append(args, data.ExtraArgs...), err
+}
+
 // Prefix adds an expression to the beginning of the query
 func (b SelectBuilder) Prefix(sql string, args ...any) SelectBuilder {
 	return b.PrefixExpr(Expr(sql, args...))
@@ -208,7 +571,13 @@ func (b SelectBuilder) Options(options ...string) SelectBuilder {
 	return Set[SelectBuilder, []string](b, "Options", options)
 }
 
-// Columns adds result columns to the query.
+// Hint adds optimizer hints to the query, rendered as a comment right after
+// SELECT, e.g. .Hint("INDEX(u idx_age)") renders "SELECT /*+ INDEX(u idx_age) */ ...".
+func (b SelectBuilder) Hint(hints ...string) SelectBuilder {
+	return Extend[SelectBuilder, string](b, "Hints", hints)
+}
+
+// Columns sets the result columns of the query, replacing any set before.
 func (b SelectBuilder) Columns(columns ...string) SelectBuilder {
 	parts := make([]N1qlizer, 0, len(columns))
 	for _, str := range columns {
@@ -217,13 +586,84 @@ func (b SelectBuilder) Columns(columns ...string) SelectBuilder {
 	return Set[SelectBuilder, []N1qlizer](b, "Columns", parts)
 }
 
-// Column adds a result column to the query.
+// addColumns adds result columns after those already set, including ones
+// set with Columns.
+func (b SelectBuilder) addColumns(parts ...N1qlizer) SelectBuilder {
+	data := GetStruct(b).(selectData)
+	columns := append(append([]N1qlizer{}, data.Columns...), parts...)
+	return Set[SelectBuilder, []N1qlizer](b, "Columns", columns)
+}
+
+// Column adds a result column to the query, after those set with Columns.
 // Unlike Columns, Column accepts args which will be bound to placeholders in
 // the column string, for example:
 //
 //	.Column("IF(n_subscribers > ?, ?, ?)", 100, "HIGH", "LOW")
 func (b SelectBuilder) Column(column any, args ...any) SelectBuilder {
-	return Append[SelectBuilder, N1qlizer](b, "Columns", Expr(column, args...))
+	return b.addColumns(Expr(column, args...))
+}
+
+// ColumnAs adds an aliased result column to the query. expr is either a
+// string, which may contain placeholders bound to args, or a N1qlizer, for
+// example:
+//
+//	.ColumnAs("IFMISSING(nickname, ?)", "name", "anonymous")
+//
+// renders "(IFMISSING(nickname, ?)) AS name".
+func (b SelectBuilder) ColumnAs(expr any, alias string, args ...any) SelectBuilder {
+	return b.addColumns(Alias(Expr(expr, args...), alias))
+}
+
+// SelectRaw sets the single result expression of a SELECT RAW query, which
+// returns the bare values instead of objects, e.g.
+//
+//	.SelectRaw("META().id").From("users")
+//
+// renders "SELECT RAW META().id FROM users". It cannot be combined with other
+// result columns or with Distinct.
+func (b SelectBuilder) SelectRaw(expr any, args ...any) SelectBuilder {
+	b = Set[SelectBuilder, bool](b, "Raw", true)
+	return b.Column(expr, args...)
+}
+
+// SelfColumn adds an "alias.*" result column, projecting every field of the
+// document bound to alias, for example:
+//
+//	Select().SelfColumn("b").From("bucket b")
+//
+// renders "SELECT b.* FROM bucket b".
+func (b SelectBuilder) SelfColumn(alias string) SelectBuilder {
+	return b.addColumns(newPart(alias + ".*"))
+}
+
+// WithMeta adds a "META(alias).id" result column, the document key of the
+// document bound to alias. Combined with SelfColumn it gives the common
+// full-document projection:
+//
+//	Select().WithMeta("b").SelfColumn("b").From("bucket b")
+//
+// renders "SELECT META(b).id, b.* FROM bucket b".
+func (b SelectBuilder) WithMeta(alias string) SelectBuilder {
+	return b.addColumns(newPart(fmt.Sprintf("META(%s).id", alias)))
+}
+
+// ColumnsMap adds aliased result columns to the query. Keys are column
+// expressions and values are their aliases, rendered as "expr AS alias" in
+// sorted key order, for example:
+//
+//	.ColumnsMap(map[string]string{"COUNT(*)": "total", "MAX(age)": "oldest"})
+func (b SelectBuilder) ColumnsMap(columns map[string]string) SelectBuilder {
+	exprs := make([]string, 0, len(columns))
+	for e := range columns {
+		exprs = append(exprs, e)
+	}
+	sort.Strings(exprs)
+
+	parts := make([]N1qlizer, 0, len(columns))
+	for _, e := range exprs {
+		parts = append(parts, newPart(fmt.Sprintf("%s AS %s", e, columns[e])))
+	}
+	return b.addColumns(parts...)
 }
 
 // From sets the FROM clause of the query.
@@ -231,9 +671,38 @@ func (b SelectBuilder) From(from string) SelectBuilder {
 	return Set[SelectBuilder, N1qlizer](b, "From", newPart(from))
 }
 
-// UseKeys sets the USE KEYS clause of the query.
-func (b SelectBuilder) UseKeys(keys string) SelectBuilder {
-	return Set[SelectBuilder, string](b, "UseKeys", keys)
+// FromMulti sets a FROM clause over several keyspaces joined with commas,
+// for example:
+//
+//	.FromMulti("users u", "orders o").Where("o.userId = META(u).id")
+func (b SelectBuilder) FromMulti(keyspaces ...string) SelectBuilder {
+	return b.From(strings.Join(keyspaces, ", "))
+}
+
+// UseKeys sets the USE KEYS clause of the query. keys may contain
+// placeholders bound to args, e.g. .UseKeys("?", []string{"k1", "k2"}).
+func (b SelectBuilder) UseKeys(keys string, args ...any) SelectBuilder {
+	return Set[SelectBuilder, N1qlizer](b, "UseKeys", Expr(keys, args...))
+}
+
+// ByKey restricts the query to the document with the given key, bound as an
+// arg: "USE KEYS ?".
+func (b SelectBuilder) ByKey(key string) SelectBuilder {
+	return b.UseKeys("?", key)
+}
+
+// UseKeysSelect sets a subquery as the USE KEYS clause of the query, for
+// example:
+//
+//	.UseKeysSelect(Select("RAW id").From("orders").Where("total > ?", 100))
+func (b SelectBuilder) UseKeysSelect(sub SelectBuilder) SelectBuilder {
+	return Set[SelectBuilder, N1qlizer](b, "UseKeys", subquery{query: sub})
+}
+
+// UseIndex sets the USE INDEX clause of the query, following the FROM
+// keyspace, e.g. .From("users u").UseIndex(UseIndexGSI("idx_age")).
+func (b SelectBuilder) UseIndex(idx UseIndex) SelectBuilder {
+	return Set[SelectBuilder, N1qlizer](b, "UseIndex", idx)
 }
 
 // FromSelect sets a subquery into the FROM clause of the query.
@@ -266,22 +735,154 @@ func (b SelectBuilder) InnerJoin(join string, rest ...any) SelectBuilder {
 	return b.JoinClause("INNER JOIN "+join, rest...)
 }
 
+// JoinOnKeys adds a lookup JOIN clause to the query, joining the documents
+// whose keys are given by the keys expression, e.g.
+//
+//	.From("gateway g").UseKeys("?", "gw1").JoinOnKeys("device d", "g.devices")
+//
+// renders "FROM gateway g USE KEYS ? JOIN device d ON KEYS g.devices".
+func (b SelectBuilder) JoinOnKeys(keyspace, keys string) SelectBuilder {
+	return b.JoinClause(fmt.Sprintf("JOIN %s ON KEYS %s", keyspace, keys))
+}
+
+// LeftJoinOnKeys adds a lookup LEFT JOIN clause to the query. See JoinOnKeys.
+func (b SelectBuilder) LeftJoinOnKeys(keyspace, keys string) SelectBuilder {
+	return b.JoinClause(fmt.Sprintf("LEFT JOIN %s ON KEYS %s", keyspace, keys))
+}
+
 // Where adds an expression to the WHERE clause of the query.
+// A nil pred adds nothing, and a bool pred adds the constant condition 1=1
+// (true) or 1=0 (false).
 func (b SelectBuilder) Where(pred any, args ...any) SelectBuilder {
-	return Append[SelectBuilder, N1qlizer](b, "WhereParts", Expr(pred, args...))
+	if pred == nil {
+		return b
+	}
+	return Append[SelectBuilder, N1qlizer](b, "WhereParts", predicate(pred, args...))
+}
+
+// WhereStruct adds an equality condition to the WHERE clause of the query
+// for each non-zero field of the struct v, or the struct v points to, joined
+// with AND, so that a partially-filled filter struct queries by example:
+//
+//	type UserFilter struct {
+//		Country string `json:"country"`
+//		Age     int    `json:"age"`
+//		Active  *bool  `json:"active"`
+//		Admin   bool   `json:"admin" n1qlizer:"includezero"`
+//	}
+//
+//	.WhereStruct(UserFilter{Country: "NL", Active: &yes})
+//
+// renders "WHERE active = ? AND admin = ? AND country = ?". Fields are named
+// by their json tag, or by the field name if it has none, and fields tagged
+// json:"-" and unexported fields are skipped. Zero values are skipped unless
+// the field is tagged n1qlizer:"includezero". Pointer fields are compared
+// with the value they point to, even a zero value, and skipped if nil; a nil
+// includezero pointer field renders "IS NULL". A nil v adds nothing, and
+// ToN1ql returns an error if v is not a struct.
+func (b SelectBuilder) WhereStruct(v any) SelectBuilder {
+	return b.Where(structEq{v: v})
 }
 
-// GroupBy adds GROUP BY expressions to the query.
+// WhereIn adds a "column IN (...)" expression to the WHERE clause of the
+// query. valuesOrSubquery may be a SelectBuilder, a slice of values or a
+// single value; see In.
+func (b SelectBuilder) WhereIn(column string, valuesOrSubquery any) SelectBuilder {
+	return b.Where(In(column, valuesOrSubquery))
+}
+
+// DedupeWhere drops WHERE expressions that render to exactly the same N1QL
+// and args as an earlier expression when the query is built.
+func (b SelectBuilder) DedupeWhere() SelectBuilder {
+	return Set[SelectBuilder, bool](b, "DedupeWhere", true)
+}
+
+// GroupBy sets the GROUP BY expressions of the query, replacing any set
+// before, including ones added with GroupByExpr.
 func (b SelectBuilder) GroupBy(groupBys ...string) SelectBuilder {
-	return Set[SelectBuilder, []string](b, "GroupBys", groupBys)
+	parts := make([]N1qlizer, len(groupBys))
+	for i, g := range groupBys {
+		parts[i] = newPart(g)
+	}
+	return Set[SelectBuilder, []N1qlizer](b, "GroupBys", parts)
+}
+
+// GroupByExpr adds a GROUP BY expression with bound args to the query, after
+// those set with GroupBy, e.g.
+//
+//	.GroupBy("u.country").GroupByExpr("DATE_TRUNC_STR(u.created, ?)", "month")
+//
+// renders "GROUP BY u.country, DATE_TRUNC_STR(u.created, ?)".
+func (b SelectBuilder) GroupByExpr(expr any, args ...any) SelectBuilder {
+	data := GetStruct(b).(selectData)
+	parts := append(append([]N1qlizer{}, data.GroupBys...), Expr(expr, args...))
+	return Set[SelectBuilder, []N1qlizer](b, "GroupBys", parts)
 }
 
-// Having adds an expression to the HAVING clause of the query.
+// DistinctOn emulates Postgres' DISTINCT ON, which N1QL lacks, by grouping
+// the query on keyFields and keeping the smallest value of pick for each
+// group. It adds keyFields and "MIN(pick) AS `first`" as result columns, the
+// alias escaped as FIRST is a reserved word, and sets GROUP BY keyFields,
+// replacing any set before. N1QL compares arrays element by element, so a
+// pick of the form "[sortExpr, value]" keeps the value of the row with the
+// smallest sortExpr, e.g. the first order of each user:
+//
+//	Select().From("orders o").DistinctOn([]string{"o.userId"}, "[o.createdAt, o]")
+//
+// renders "SELECT o.userId, MIN([o.createdAt, o]) AS `first` FROM orders o
+// GROUP BY o.userId", where `first`[1] is the order.
+func (b SelectBuilder) DistinctOn(keyFields []string, pick string) SelectBuilder {
+	parts := make([]N1qlizer, 0, len(keyFields)+1)
+	for _, f := range keyFields {
+		parts = append(parts, newPart(f))
+	}
+	parts = append(parts, newPart(fmt.Sprintf("MIN(%s) AS `first`", pick)))
+	return b.addColumns(parts...).GroupBy(keyFields...)
+}
+
+// AutoGroupBy makes the query group by its non-aggregate result columns when
+// it is built, so that they need not be repeated in GroupBy, e.g.
+//
+//	Select("u.country", "COUNT(*) AS users").From("users u").AutoGroupBy()
+//
+// renders "SELECT u.country, COUNT(*) AS users FROM users u GROUP BY
+// u.country". The rules are:
+//
+//   - A column is an aggregate if it calls COUNT, SUM, AVG, MIN, MAX or
+//     ARRAY_AGG anywhere outside a quoted literal, e.g. "ROUND(AVG(age), 1)".
+//   - Nothing is grouped unless at least one column is an aggregate.
+//   - Every other column is grouped by its expression, without a trailing
+//     "AS alias", and with its args; columns already in GroupBy are skipped.
+//   - Columns that are not built from strings, such as subqueries, are neither
+//     inspected nor grouped.
+//
+// ToN1ql returns an error if a non-aggregate column is a "*" projection.
+func (b SelectBuilder) AutoGroupBy() SelectBuilder {
+	return Set[SelectBuilder, bool](b, "AutoGroup", true)
+}
+
+// Having adds an expression to the HAVING clause of the query, which requires
+// a GROUP BY or an aggregate result column; without a GROUP BY the whole
+// result is one group, e.g.
+//
+//	Select("COUNT(*) AS c").From("x").Having("COUNT(*) > ?", 1)
+//
+// Expressions are joined with AND in the order they are added. A nil pred
+// adds nothing.
 func (b SelectBuilder) Having(pred any, rest ...any) SelectBuilder {
+	if pred == nil {
+		return b
+	}
 	return Append[SelectBuilder, N1qlizer](b, "HavingParts", Expr(pred, rest...))
 }
 
-// OrderBy adds ORDER BY expressions to the query.
+// OrderBy sets the ORDER BY expressions of the query. Each expression may end
+// with a direction and a NULLS ordering, for example:
+//
+//	.OrderBy("age DESC NULLS LAST", "name")
+//
+// ToN1ql returns an error for an expression whose trailing keywords are not of
+// the form "[ASC|DESC] [NULLS FIRST|NULLS LAST]".
 func (b SelectBuilder) OrderBy(orderBys ...string) SelectBuilder {
 	parts := make([]N1qlizer, 0, len(orderBys))
 	for _, str := range orderBys {
@@ -290,6 +891,41 @@ func (b SelectBuilder) OrderBy(orderBys ...string) SelectBuilder {
 	return Set[SelectBuilder, []N1qlizer](b, "OrderByParts", parts)
 }
 
+// SortDir is the direction of an ORDER BY expression.
+type SortDir string
+
+const (
+	// Asc sorts in ascending order.
+	Asc SortDir = "ASC"
+	// Desc sorts in descending order.
+	Desc SortDir = "DESC"
+)
+
+// orderByDir renders a field with a SortDir.
+type orderByDir struct {
+	field string
+	dir   SortDir
+}
+
+func (o orderByDir) ToN1ql() (string, []any, error) {
+	if o.dir != Asc && o.dir != Desc {
+		return "", nil, fmt.Errorf("order by direction must be Asc or Desc, got %q", string(o.dir))
+	}
+	return fmt.Sprintf("%s %s", o.field, o.dir), nil, nil
+}
+
+// OrderByDir adds an ORDER BY expression sorting by field in direction dir to
+// the query, after those added before, e.g.
+//
+//	.OrderByDir("age", Desc).OrderByDir("name", Asc)
+//
+// renders "ORDER BY age DESC, name ASC".
+func (b SelectBuilder) OrderByDir(field string, dir SortDir) SelectBuilder {
+	data := GetStruct(b).(selectData)
+	parts := append(append([]N1qlizer{}, data.OrderByParts...), orderByDir{field: field, dir: dir})
+	return Set[SelectBuilder, []N1qlizer](b, "OrderByParts", parts)
+}
+
 // OrderByClause adds ORDER BY expressions to the query with a custom clause.
 //
 // This is a more flexible version of OrderBy, and can be used for complex
@@ -298,6 +934,20 @@ func (b SelectBuilder) OrderByClause(pred any, args ...any) SelectBuilder {
 	return Append[SelectBuilder, N1qlizer](b, "OrderByParts", Expr(pred, args...))
 }
 
+// OrderByExpr adds a computed ORDER BY expression to the query, such as a
+// CASE expression:
+//
+//	.OrderByExpr(NewCaseBuilder().When(Eq{"status": "vip"}, 0).Else(1))
+func (b SelectBuilder) OrderByExpr(expr N1qlizer) SelectBuilder {
+	return Append[SelectBuilder, N1qlizer](b, "OrderByParts", expr)
+}
+
+// OrderByRandom orders the results randomly with "ORDER BY RANDOM()", e.g.
+// to preview a sample of documents together with Limit.
+func (b SelectBuilder) OrderByRandom() SelectBuilder {
+	return b.OrderByExpr(newPart("RANDOM()"))
+}
+
 // Limit sets a LIMIT clause on the query.
 func (b SelectBuilder) Limit(limit uint64) SelectBuilder {
 	return Set[SelectBuilder, string](b, "Limit", fmt.Sprintf("%d", limit))
@@ -308,6 +958,24 @@ func (b SelectBuilder) Offset(offset uint64) SelectBuilder {
 	return Set[SelectBuilder, string](b, "Offset", fmt.Sprintf("%d", offset))
 }
 
+// DefaultLimit sets a LIMIT applied only if neither Limit nor LimitExpr is
+// set, as a guard against unbounded scans.
+func (b SelectBuilder) DefaultLimit(limit uint64) SelectBuilder {
+	return Set[SelectBuilder, string](b, "DefaultLimit", fmt.Sprintf("%d", limit))
+}
+
+// LimitExpr sets a computed LIMIT clause on the query, e.g.
+// .LimitExpr(Expr("ARRAY_COUNT(?)", ids)). It takes precedence over Limit.
+func (b SelectBuilder) LimitExpr(expr N1qlizer) SelectBuilder {
+	return Set[SelectBuilder, N1qlizer](b, "LimitExpr", expr)
+}
+
+// OffsetExpr sets a computed OFFSET clause on the query. It takes precedence
+// over Offset.
+func (b SelectBuilder) OffsetExpr(expr N1qlizer) SelectBuilder {
+	return Set[SelectBuilder, N1qlizer](b, "OffsetExpr", expr)
+}
+
 // Suffix adds an expression to the end of the query
 func (b SelectBuilder) Suffix(sql string, args ...any) SelectBuilder {
 	return b.SuffixExpr(Expr(sql, args...))
@@ -317,3 +985,234 @@ func (b SelectBuilder) Suffix(sql string, args ...any) SelectBuilder {
 func (b SelectBuilder) SuffixExpr(expr N1qlizer) SelectBuilder {
 	return Append[SelectBuilder, N1qlizer](b, "Suffixes", expr)
 }
+
+// setOperation appends a set operation combining this query with other.
+func (b SelectBuilder) setOperation(operator string, other SelectBuilder) SelectBuilder {
+	return Append[SelectBuilder, N1qlizer](b, "SetOperations", setOperation{operator: operator, query: other})
+}
+
+// Union combines the results of the query with another query using UNION.
+// Set operations are rendered before the query's ORDER BY, LIMIT and OFFSET,
+// which apply to the combined result; other should not have them.
+func (b SelectBuilder) Union(other SelectBuilder) SelectBuilder {
+	return b.setOperation("UNION", other)
+}
+
+// UnionAll combines the results of the query with another query using UNION ALL.
+func (b SelectBuilder) UnionAll(other SelectBuilder) SelectBuilder {
+	return b.setOperation("UNION ALL", other)
+}
+
+// Intersect combines the results of the query with another query using INTERSECT.
+func (b SelectBuilder) Intersect(other SelectBuilder) SelectBuilder {
+	return b.setOperation("INTERSECT", other)
+}
+
+// IntersectAll combines the results of the query with another query using INTERSECT ALL.
+func (b SelectBuilder) IntersectAll(other SelectBuilder) SelectBuilder {
+	return b.setOperation("INTERSECT ALL", other)
+}
+
+// Except combines the results of the query with another query using EXCEPT.
+func (b SelectBuilder) Except(other SelectBuilder) SelectBuilder {
+	return b.setOperation("EXCEPT", other)
+}
+
+// ExceptAll combines the results of the query with another query using EXCEPT ALL.
+func (b SelectBuilder) ExceptAll(other SelectBuilder) SelectBuilder {
+	return b.setOperation("EXCEPT ALL", other)
+}
+
+// cte renders a common table expression of a WITH clause.
+type cte struct {
+	name      string
+	query     N1qlizer
+	recursive N1qlizer
+}
+
+func (c cte) ToN1ql() (string, []any, error) {
+	sql, args, err := nestedToN1ql(c.query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if c.recursive != nil {
+		rsql, rargs, err := nestedToN1ql(c.recursive)
+		if err != nil {
+			return "", nil, err
+		}
+		sql = sql + " UNION " + rsql
+		args = append(args, rargs...)
+	}
+
+	return fmt.Sprintf("%s AS (%s)", c.name, sql), args, nil
+}
+
+// strictParts returns the parts of the query checked by StrictMode.
+func (d *selectData) strictParts() []N1qlizer {
+	var parts []N1qlizer
+	for _, p := range [][]N1qlizer{d.Prefixes, d.Columns, {d.From, d.UseKeys}, d.Joins, d.WhereParts, d.GroupBys, d.HavingParts, d.OrderByParts, d.Suffixes} {
+		parts = append(parts, p...)
+	}
+	return parts
+}
+
+// checkOrderBy returns an error if an ORDER BY expression built from a string
+// has misplaced or incomplete ordering keywords, e.g. "name NULLS" or
+// "name NULLS FIRST DESC". Only the trailing keywords are checked; the
+// expression itself is passed to N1QL as is.
+func checkOrderBy(parts ...N1qlizer) error {
+	for _, part := range parts {
+		e, ok := part.(expr)
+		if !ok {
+			continue
+		}
+
+		words := strings.Fields(e.sql)
+		n := len(words)
+		last := func() string { return strings.ToUpper(words[n-1]) }
+
+		if n >= 2 && (last() == "FIRST" || last() == "LAST") && strings.EqualFold(words[n-2], "NULLS") {
+			n -= 2
+		} else if n > 0 && last() == "NULLS" {
+			return fmt.Errorf("order by expression %q: NULLS must be followed by FIRST or LAST", e.sql)
+		}
+		if n > 0 && (last() == "ASC" || last() == "DESC") {
+			n--
+		}
+
+		switch {
+		case n == 0:
+			return fmt.Errorf("order by expression %q has no expression to order by", e.sql)
+		case last() == "ASC" || last() == "DESC":
+			return fmt.Errorf("order by expression %q has more than one direction", e.sql)
+		case n >= 2 && (last() == "FIRST" || last() == "LAST") && strings.EqualFold(words[n-2], "NULLS"):
+			return fmt.Errorf("order by expression %q: NULLS %s must follow the direction", e.sql, last())
+		case last() == "NULLS":
+			return fmt.Errorf("order by expression %q: NULLS must be followed by FIRST or LAST", e.sql)
+		}
+	}
+	return nil
+}
+
+// aggregateFunctions are the functions AutoGroupBy and the HAVING check treat
+// as aggregates.
+var aggregateFunctions = map[string]bool{
+	"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true, "ARRAY_AGG": true,
+}
+
+// autoGroupBys returns the GROUP BY expressions of the query followed by its
+// non-aggregate result columns, as described on AutoGroupBy.
+func (d *selectData) autoGroupBys() ([]N1qlizer, error) {
+	var candidates []expr
+	aggregated := false
+	for _, column := range d.Columns {
+		if a, ok := column.(aliasExpr); ok {
+			column = a.expr
+		}
+		e, ok := column.(expr)
+		if !ok {
+			continue
+		}
+		if isAggregate(e.sql) {
+			aggregated = true
+			continue
+		}
+		candidates = append(candidates, expr{sql: stripColumnAlias(e.sql), args: e.args})
+	}
+
+	groupBys := d.GroupBys
+	if !aggregated {
+		return groupBys, nil
+	}
+
+	seen := make(map[string]bool, len(groupBys))
+	for _, g := range groupBys {
+		if e, ok := g.(expr); ok && len(e.args) == 0 {
+			seen[e.sql] = true
+		}
+	}
+
+	groupBys = append([]N1qlizer{}, groupBys...)
+	for _, c := range candidates {
+		if strings.HasSuffix(c.sql, "*") {
+			return nil, fmt.Errorf("select statements with AutoGroupBy cannot group by %q", c.sql)
+		}
+		if len(c.args) == 0 {
+			if seen[c.sql] {
+				continue
+			}
+			seen[c.sql] = true
+		}
+		groupBys = append(groupBys, c)
+	}
+	return groupBys, nil
+}
+
+// hasAggregateColumn reports whether any result column calls an aggregate
+// function.
+func (d *selectData) hasAggregateColumn() bool {
+	for _, column := range d.Columns {
+		if a, ok := column.(aliasExpr); ok {
+			column = a.expr
+		}
+		if e, ok := column.(expr); ok && isAggregate(e.sql) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAggregate reports whether sql calls one of the aggregateFunctions outside
+// of quoted literals and identifiers.
+func isAggregate(sql string) bool {
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if c == '\'' || c == '"' || c == '`' {
+			end := closingQuote(sql, i)
+			if end < 0 {
+				return false
+			}
+			i = end
+			continue
+		}
+		if !isIdentByte(c) || (i > 0 && (isIdentByte(sql[i-1]) || sql[i-1] == '.')) {
+			continue
+		}
+
+		j := i
+		for j < len(sql) && isIdentByte(sql[j]) {
+			j++
+		}
+		word := strings.ToUpper(sql[i:j])
+		i = j - 1
+		for j < len(sql) && sql[j] == ' ' {
+			j++
+		}
+		if aggregateFunctions[word] && j < len(sql) && sql[j] == '(' {
+			return true
+		}
+	}
+	return false
+}
+
+// stripColumnAlias removes a trailing "AS alias" from a result column.
+func stripColumnAlias(column string) string {
+	fields := strings.Fields(column)
+	n := len(fields)
+	if n < 3 || !strings.EqualFold(fields[n-2], "AS") {
+		return column
+	}
+	alias := fields[n-1]
+	if strings.ContainsAny(alias, "()'\"") {
+		return column
+	}
+
+	trimmed := strings.TrimRight(column, " \t\n")
+	trimmed = strings.TrimRight(trimmed[:len(trimmed)-len(alias)], " \t\n")
+	return strings.TrimRight(trimmed[:len(trimmed)-len(fields[n-2])], " \t\n")
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,1391 @@
+package n1qlizer
+
+import (
+	"testing"
+)
+
+// TestSelectSetOperations tests UNION, INTERSECT and EXCEPT set operations
+func TestSelectSetOperations(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	left := sb.Select("id").From("users").Where("age > ?", 18)
+	right := Select("id").From("admins").Where("level = ?", 2)
+	third := Select("id").From("banned").Where("reason = ?", "spam")
+
+	testCases := []struct {
+		name     string
+		builder  SelectBuilder
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "UNION",
+			builder:  left.Union(right),
+			expected: "SELECT id FROM users WHERE age > $1 UNION SELECT id FROM admins WHERE level = $2",
+			args:     []interface{}{18, 2},
+		},
+		{
+			name:     "UNION ALL",
+			builder:  left.UnionAll(right),
+			expected: "SELECT id FROM users WHERE age > $1 UNION ALL SELECT id FROM admins WHERE level = $2",
+			args:     []interface{}{18, 2},
+		},
+		{
+			name:     "INTERSECT",
+			builder:  left.Intersect(right),
+			expected: "SELECT id FROM users WHERE age > $1 INTERSECT SELECT id FROM admins WHERE level = $2",
+			args:     []interface{}{18, 2},
+		},
+		{
+			name:     "INTERSECT ALL",
+			builder:  left.IntersectAll(right),
+			expected: "SELECT id FROM users WHERE age > $1 INTERSECT ALL SELECT id FROM admins WHERE level = $2",
+			args:     []interface{}{18, 2},
+		},
+		{
+			name:     "EXCEPT",
+			builder:  left.Except(right),
+			expected: "SELECT id FROM users WHERE age > $1 EXCEPT SELECT id FROM admins WHERE level = $2",
+			args:     []interface{}{18, 2},
+		},
+		{
+			name:     "EXCEPT ALL",
+			builder:  left.ExceptAll(right),
+			expected: "SELECT id FROM users WHERE age > $1 EXCEPT ALL SELECT id FROM admins WHERE level = $2",
+			args:     []interface{}{18, 2},
+		},
+		{
+			name:     "Chained set operations",
+			builder:  left.Intersect(right).ExceptAll(third),
+			expected: "SELECT id FROM users WHERE age > $1 INTERSECT SELECT id FROM admins WHERE level = $2 EXCEPT ALL SELECT id FROM banned WHERE reason = $3",
+			args:     []interface{}{18, 2, "spam"},
+		},
+		{
+			name:     "ORDER BY, LIMIT and OFFSET apply to the combined result",
+			builder:  left.Limit(5).OrderBy("id DESC").Offset(10).Union(right),
+			expected: "SELECT id FROM users WHERE age > $1 UNION SELECT id FROM admins WHERE level = $2 ORDER BY id DESC LIMIT 5 OFFSET 10",
+			args:     []interface{}{18, 2},
+		},
+		{
+			name:     "Dollar formatted operand",
+			builder:  left.Except(right.PlaceholderFormat(Dollar)),
+			expected: "SELECT id FROM users WHERE age > $1 EXCEPT SELECT id FROM admins WHERE level = $2",
+			args:     []interface{}{18, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+
+			if len(args) != len(tc.args) {
+				t.Errorf("Wrong number of args: Expected %d, got %d", len(tc.args), len(args))
+				return
+			}
+
+			for i, arg := range args {
+				if arg != tc.args[i] {
+					t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, tc.args[i], arg)
+				}
+			}
+		})
+	}
+}
+
+// TestSelectDedupeWhere tests dropping duplicate WHERE expressions
+func TestSelectDedupeWhere(t *testing.T) {
+	active := Eq{"status": "active"}
+
+	t.Run("Drops exact duplicates", func(t *testing.T) {
+		sql, args, err := Select("*").
+			From("users").
+			Where(active).
+			Where("age > ?", 18).
+			Where(active).
+			Where("age > ?", 18).
+			DedupeWhere().
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		expected := "SELECT * FROM users WHERE status = ? AND age > ?"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+
+		if len(args) != 2 || args[0] != "active" || args[1] != 18 {
+			t.Errorf("Wrong args: %+v", args)
+		}
+	})
+
+	t.Run("Keeps parts with different args", func(t *testing.T) {
+		sql, args, err := Select("*").
+			From("users").
+			Where("age > ?", 18).
+			Where("age > ?", 21).
+			DedupeWhere().
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		expected := "SELECT * FROM users WHERE age > ? AND age > ?"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+
+		if len(args) != 2 || args[0] != 18 || args[1] != 21 {
+			t.Errorf("Wrong args: %+v", args)
+		}
+	})
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		sql, _, err := Select("*").From("users").Where(active).Where(active).ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		expected := "SELECT * FROM users WHERE status = ? AND status = ?"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+	})
+}
+
+// TestSelectUseKeysSelect tests USE KEYS with a subquery
+func TestSelectUseKeysSelect(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sub := sb.Select("RAW o.userId").From("orders o").Where("o.total > ?", 100)
+	sql, args, err := sb.Select("*").
+		From("users").
+		UseKeysSelect(sub).
+		Where("status = ?", "active").
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users USE KEYS (SELECT RAW o.userId FROM orders o WHERE o.total > $1) WHERE status = $2"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 2 || args[0] != 100 || args[1] != "active" {
+		t.Errorf("Wrong args: %+v", args)
+	}
+}
+
+// TestStartPlaceholdersAt tests numbering placeholders after an offset
+func TestStartPlaceholdersAt(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	testCases := []struct {
+		name     string
+		builder  N1qlizer
+		expected string
+	}{
+		{
+			name:     "SELECT with Dollar format",
+			builder:  sb.Select("*").From("users").Where("id = ? AND age > ?", 1, 18).StartPlaceholdersAt(3),
+			expected: "SELECT * FROM users WHERE id = $4 AND age > $5",
+		},
+		{
+			name:     "UPDATE with Dollar format",
+			builder:  sb.Update("users").Set("name", "John").Where("id = ?", 1).StartPlaceholdersAt(2),
+			expected: "UPDATE users SET name = $3 WHERE id = $4",
+		},
+		{
+			name:     "Question format ignores offset",
+			builder:  Select("*").From("users").Where("id = ?", 1).StartPlaceholdersAt(3),
+			expected: "SELECT * FROM users WHERE id = ?",
+		},
+		{
+			name:     "Zero offset",
+			builder:  sb.Delete("users").Where("id = ?", 1).StartPlaceholdersAt(0),
+			expected: "DELETE FROM users WHERE id = $1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, _, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+		})
+	}
+}
+
+// TestSelectOrderByExpr tests ordering by a computed expression
+func TestSelectOrderByExpr(t *testing.T) {
+	priority := NewCaseBuilder().
+		When(Eq{"status": "vip"}, 0).
+		When(Eq{"status": "member"}, 1).
+		Else(2)
+
+	sql, args, err := Select("*").
+		From("users").
+		Where("age > ?", 18).
+		OrderByExpr(priority).
+		OrderByClause("name ASC").
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users WHERE age > ? ORDER BY CASE WHEN status = ? THEN ? WHEN status = ? THEN ? ELSE ? END, name ASC"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{18, "vip", 0, "member", 1, 2}
+	if len(args) != len(expectedArgs) {
+		t.Fatalf("Wrong number of args: Expected %d, got %d", len(expectedArgs), len(args))
+	}
+
+	for i, arg := range args {
+		if arg != expectedArgs[i] {
+			t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, expectedArgs[i], arg)
+		}
+	}
+}
+
+// TestSelectColumnsMap tests aliased columns from a map
+func TestSelectColumnsMap(t *testing.T) {
+	sql, args, err := Select("country").
+		ColumnsMap(map[string]string{"MAX(age)": "oldest", "COUNT(*)": "total"}).
+		Column("SUM(score) > ?", 100).
+		From("users").
+		GroupBy("country").
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT country, COUNT(*) AS total, MAX(age) AS oldest, SUM(score) > ? FROM users GROUP BY country"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != 100 {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	sql, _, err = Select("a").Columns("b").ColumnsMap(map[string]string{"c": "d"}).Columns("e").From("x").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	if sql != "SELECT e FROM x" {
+		t.Errorf("Expected Columns to replace earlier columns, got %s", sql)
+	}
+
+	sql, _, err = AnalyticsSelect("a").Columns("b").From("x").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	if sql != "SELECT b FROM x" {
+		t.Errorf("Expected Columns to replace earlier columns, got %s", sql)
+	}
+}
+
+// TestSelectCorrelatedSubquery tests EXISTS subqueries referencing outer aliases
+func TestSelectCorrelatedSubquery(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	orders := sb.Select("1").
+		From("orders o").
+		Where("o.userId = META(u).id").
+		Where("o.total > ?", 100)
+
+	testCases := []struct {
+		name     string
+		builder  SelectBuilder
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "EXISTS",
+			builder:  sb.Select("u.name").From("users u").Where("u.active = ?", true).Where(Exists(orders)),
+			expected: "SELECT u.name FROM users u WHERE u.active = $1 AND EXISTS (SELECT 1 FROM orders o WHERE o.userId = META(u).id AND o.total > $2)",
+			args:     []interface{}{true, 100},
+		},
+		{
+			name:     "NOT EXISTS",
+			builder:  sb.Select("u.name").From("users u").Where(NotExists(orders)).Where("u.age > ?", 18),
+			expected: "SELECT u.name FROM users u WHERE NOT EXISTS (SELECT 1 FROM orders o WHERE o.userId = META(u).id AND o.total > $1) AND u.age > $2",
+			args:     []interface{}{100, 18},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+
+			if len(args) != len(tc.args) {
+				t.Fatalf("Wrong number of args: Expected %d, got %d", len(tc.args), len(args))
+			}
+
+			for i, arg := range args {
+				if arg != tc.args[i] {
+					t.Errorf("Wrong arg at position %d: Expected %v, got %v", i, tc.args[i], arg)
+				}
+			}
+		})
+	}
+}
+
+// TestSelectFromMulti tests FROM clauses over several keyspaces
+func TestSelectFromMulti(t *testing.T) {
+	sql, args, err := Select("u.name", "o.total").
+		FromMulti("users u", "orders o").
+		Where("o.userId = META(u).id").
+		Where("o.total > ?", 100).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT u.name, o.total FROM users u, orders o WHERE o.userId = META(u).id AND o.total > ?"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != 100 {
+		t.Errorf("Wrong args: %+v", args)
+	}
+}
+
+// TestSelectHint tests optimizer hint comments
+func TestSelectHint(t *testing.T) {
+	sql, args, err := Select("name").
+		Distinct().
+		Hint("INDEX(u idx_age)").
+		Hint("USE_HASH(o)").
+		From("users u").
+		Where("u.age > ?", 18).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT /*+ INDEX(u idx_age) USE_HASH(o) */ DISTINCT name FROM users u WHERE u.age > ?"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != 18 {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	sql, _, err = AnalyticsSelect("*").Hint("hash-bcast").From("users").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "SELECT /*+ hash-bcast */ * FROM users" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	if _, _, err := Select("*").Hint("INDEX(u idx) */ DELETE").From("users").ToN1ql(); err == nil {
+		t.Error("Expected error for hint closing the comment, got nil")
+	}
+}
+
+// TestSelectLimitExpr tests computed LIMIT and OFFSET clauses
+func TestSelectLimitExpr(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Select("*").
+		From("users").
+		Where("age > ?", 18).
+		Limit(10).
+		LimitExpr(Expr("ARRAY_COUNT(?)", []string{"a", "b"})).
+		Offset(5).
+		OffsetExpr(Expr("? * ?", 2, 20)).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users WHERE age > $1 LIMIT ARRAY_COUNT($2) OFFSET $3 * $4"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{18, []string{"a", "b"}, 2, 20}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+// TestSelectOrderByRandom tests random ordering for sampling
+func TestSelectOrderByRandom(t *testing.T) {
+	sql, args, err := Select("*").From("users").Where("active = ?", true).OrderByRandom().Limit(10).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users WHERE active = ? ORDER BY RANDOM() LIMIT 10"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != true {
+		t.Errorf("Wrong args: %+v", args)
+	}
+}
+
+// TestSelectToN1qlPretty tests pretty-printed output
+func TestSelectToN1qlPretty(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	query := sb.Select("u.name", "COUNT(*) AS orders").
+		From("users u").
+		Join("orders o ON KEYS u.orderIds").
+		Where("u.active = ?", true).
+		Where("u.age > ?", 18).
+		GroupBy("u.name").
+		Having("COUNT(*) > ?", 2).
+		OrderBy("orders DESC").
+		Limit(10)
+
+	sql, args, err := query.ToN1qlPretty()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT u.name, COUNT(*) AS orders\n" +
+		"FROM users u\n" +
+		"JOIN orders o ON KEYS u.orderIds\n" +
+		"WHERE u.active = $1\n" +
+		"  AND u.age > $2\n" +
+		"GROUP BY u.name\n" +
+		"HAVING COUNT(*) > $3\n" +
+		"ORDER BY orders DESC\n" +
+		"LIMIT 10"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	_, compactArgs, err := query.ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if !argsEqual(compactArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", compactArgs, args)
+	}
+}
+
+// TestSelectRaw tests SELECT RAW queries and their validation
+func TestSelectRaw(t *testing.T) {
+	sql, args, err := SelectRaw("META(u).id").From("users u").Where("u.age > ?", 18).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT RAW META(u).id FROM users u WHERE u.age > ?"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != 18 {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	_, _, err = SelectRaw("u.city").Distinct().From("users u").ToN1ql()
+	if err == nil || !contains(err.Error(), "DISTINCT") {
+		t.Errorf("Expected DISTINCT conflict error, got %v", err)
+	}
+
+	_, _, err = Select().SelectRaw("u.city").Column("u.name").From("users u").ToN1ql()
+	if err == nil {
+		t.Error("Expected error for SELECT RAW with several columns, got nil")
+	}
+}
+
+// TestSelectColumnAs tests aliased result columns
+func TestSelectColumnAs(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Select("id").
+		ColumnAs("IFMISSING(nickname, ?)", "name", "anonymous").
+		ColumnAs(NewCaseBuilder().When(Gt{"age": 17}, "adult").Else("minor"), "category").
+		From("users").
+		Where("active = ?", true).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT id, (IFMISSING(nickname, $1)) AS name, (CASE WHEN age > $2 THEN $3 ELSE $4 END) AS category FROM users WHERE active = $5"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"anonymous", 17, "adult", "minor", true}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+
+	sql, args, err = AnalyticsSelect().ColumnAs("COUNT(*)", "total").From("users").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "SELECT (COUNT(*)) AS total FROM users" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	if len(args) != 0 {
+		t.Errorf("Expected empty args, got %v", args)
+	}
+}
+
+// TestSelectWhereIn tests membership conditions over values and subqueries
+func TestSelectWhereIn(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	testCases := []struct {
+		name     string
+		builder  N1qlizer
+		expected string
+		args     []interface{}
+	}{
+		{
+			name:     "Slice",
+			builder:  sb.Select("*").From("users").Where("age > ?", 18).WhereIn("status", []string{"active", "pending"}),
+			expected: "SELECT * FROM users WHERE age > $1 AND status IN ($2,$3)",
+			args:     []interface{}{18, "active", "pending"},
+		},
+		{
+			name:     "Single value",
+			builder:  sb.Select("*").From("users").WhereIn("status", "active"),
+			expected: "SELECT * FROM users WHERE status IN ($1)",
+			args:     []interface{}{"active"},
+		},
+		{
+			name:     "Byte slice",
+			builder:  sb.Select("*").From("users").WhereIn("hash", []byte("ab")),
+			expected: "SELECT * FROM users WHERE hash IN ($1)",
+			args:     []interface{}{[]byte("ab")},
+		},
+		{
+			name:     "Unformatted nested placeholders",
+			builder:  sb.Select("*").From("users").Where("age > ?", 18).WhereIn("id", rawPart{Expr("[?, ?]", "a", "b")}),
+			expected: "SELECT * FROM users WHERE age > $1 AND id IN [$2, $3]",
+			args:     []interface{}{18, "a", "b"},
+		},
+		{
+			name:     "Empty slice",
+			builder:  sb.Select("*").From("users").WhereIn("status", []string{}),
+			expected: "SELECT * FROM users WHERE 1=0",
+			args:     nil,
+		},
+		{
+			name: "Subquery",
+			builder: sb.Select("*").From("users").
+				Where("age > ?", 18).
+				WhereIn("META().id", Select("RAW o.userId").From("orders o").Where("o.total > ?", 100)).
+				Where("active = ?", true),
+			expected: "SELECT * FROM users WHERE age > $1 AND META().id IN (SELECT RAW o.userId FROM orders o WHERE o.total > $2) AND active = $3",
+			args:     []interface{}{18, 100, true},
+		},
+		{
+			name:     "Update",
+			builder:  sb.Update("users").Set("active", false).WhereIn("id", []int{1, 2}),
+			expected: "UPDATE users SET active = $1 WHERE id IN ($2,$3)",
+			args:     []interface{}{false, 1, 2},
+		},
+		{
+			name:     "Delete",
+			builder:  sb.Delete("users").WhereIn("id", []int{1, 2}),
+			expected: "DELETE FROM users WHERE id IN ($1,$2)",
+			args:     []interface{}{1, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+
+			if !argsEqual(tc.args, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tc.args, args)
+			}
+		})
+	}
+}
+
+// TestSelectUseKeysWithJoinOnKeys tests USE KEYS together with lookup joins
+func TestSelectUseKeysWithJoinOnKeys(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Select("g.name", "d.status").
+		From("gateway g").
+		UseKeys("?", []string{"gw1", "gw2"}).
+		JoinOnKeys("device d", "g.devices").
+		LeftJoinOnKeys("owner o", "g.ownerId").
+		Where("d.status = ?", "online").
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT g.name, d.status FROM gateway g USE KEYS $1 JOIN device d ON KEYS g.devices LEFT JOIN owner o ON KEYS g.ownerId WHERE d.status = $2"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{[]string{"gw1", "gw2"}, "online"}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+// TestSelectNilPredicates tests that nil WHERE and HAVING predicates are ignored
+func TestSelectNilPredicates(t *testing.T) {
+	var cond N1qlizer
+
+	sql, args, err := Select("country", "COUNT(*)").
+		From("users").
+		Where(nil).
+		Where(cond).
+		Where("age > ?", 18).
+		GroupBy("country").
+		Having(nil).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT country, COUNT(*) FROM users WHERE age > ? GROUP BY country"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 1 || args[0] != 18 {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	sql, _, err = Update("users").Set("active", false).Where(nil).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "UPDATE users SET active = ?" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	sql, _, err = Delete("users").Where(nil).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "DELETE FROM users" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+}
+
+// TestSelectDefaultLimit tests the LIMIT safety cap
+func TestSelectDefaultLimit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		builder  N1qlizer
+		expected string
+	}{
+		{
+			name:     "Default applied",
+			builder:  Select("*").From("users").DefaultLimit(1000),
+			expected: "SELECT * FROM users LIMIT 1000",
+		},
+		{
+			name:     "Explicit limit wins",
+			builder:  Select("*").From("users").DefaultLimit(1000).Limit(10),
+			expected: "SELECT * FROM users LIMIT 10",
+		},
+		{
+			name:     "Limit expression wins",
+			builder:  Select("*").From("users").LimitExpr(Expr("5")).DefaultLimit(1000),
+			expected: "SELECT * FROM users LIMIT 5",
+		},
+		{
+			name:     "Analytics default applied",
+			builder:  AnalyticsSelect("*").From("users").DefaultLimit(1000).Offset(20),
+			expected: "SELECT * FROM users LIMIT 1000 OFFSET 20",
+		},
+		{
+			name:     "Analytics explicit limit wins",
+			builder:  AnalyticsSelect("*").From("users").DefaultLimit(1000).Limit(10),
+			expected: "SELECT * FROM users LIMIT 10",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, _, err := tc.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tc.expected, sql)
+			}
+		})
+	}
+}
+
+// TestSelectHavingWithoutGroupBy tests that HAVING requires a GROUP BY or an
+// aggregate result column
+func TestSelectHavingWithoutGroupBy(t *testing.T) {
+	_, _, err := Select("name").From("users").Having("COUNT(*) > ?", 5).ToN1ql()
+	if err == nil || !contains(err.Error(), "GROUP BY") {
+		t.Errorf("Expected GROUP BY error, got %v", err)
+	}
+
+	sql, args, err := Select("COUNT(*) AS c").From("x").Having("COUNT(*) > ?", 1).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT COUNT(*) AS c FROM x HAVING COUNT(*) > ?"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{1}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+
+	if _, _, err := Select().Column(Alias(Expr("SUM(total)"), "s")).From("x").Having("SUM(total) > ?", 10).ToN1ql(); err != nil {
+		t.Errorf("Expected aliased aggregate column to allow HAVING, got %v", err)
+	}
+}
+
+// TestSelectByKey tests restricting a SELECT to a single bound document key
+func TestSelectByKey(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Select("*").From("users").ByKey("user::1").Where("active = ?", true).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users USE KEYS $1 WHERE active = $2"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"user::1", true}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+// TestSelectWith tests common table expressions, including recursive ones
+func TestSelectWith(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	t.Run("Chained CTEs", func(t *testing.T) {
+		sql, args, err := sb.Select("*").
+			With("active", Select("*").From("users").Where("status = ?", "active")).
+			With("recent", Select("*").From("active").Where("lastLogin > ?", 100)).
+			From("recent").
+			Limit(10).
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		expected := "WITH active AS (SELECT * FROM users WHERE status = $1), " +
+			"recent AS (SELECT * FROM active WHERE lastLogin > $2) SELECT * FROM recent LIMIT 10"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+
+		expectedArgs := []interface{}{"active", 100}
+		if !argsEqual(expectedArgs, args) {
+			t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+		}
+	})
+
+	t.Run("Recursive CTE", func(t *testing.T) {
+		sql, args, err := sb.Select("c.id").
+			WithRecursive("chain",
+				Select("e.id", "e.managerId").From("employees e").Where("e.id = ?", "emp7"),
+				Select("e.id", "e.managerId").From("employees e").Join("chain c ON e.id = c.managerId").Where("e.active = ?", true)).
+			From("chain c").
+			Where("c.id != ?", "emp7").
+			ToN1ql()
+		if err != nil {
+			t.Fatalf("Failed to build query: %v", err)
+		}
+
+		expected := "WITH RECURSIVE chain AS (SELECT e.id, e.managerId FROM employees e WHERE e.id = $1 " +
+			"UNION SELECT e.id, e.managerId FROM employees e JOIN chain c ON e.id = c.managerId WHERE e.active = $2) " +
+			"SELECT c.id FROM chain c WHERE c.id != $3"
+		if sql != expected {
+			t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+		}
+
+		expectedArgs := []interface{}{"emp7", true, "emp7"}
+		if !argsEqual(expectedArgs, args) {
+			t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+		}
+	})
+}
+
+// TestSelectWhereBool tests constant conditions from Go bools
+func TestSelectWhereBool(t *testing.T) {
+	includeArchived := false
+
+	sql, args, err := Select("*").From("users").
+		Where(true).
+		Where("status = ?", "active").
+		Where(includeArchived).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT * FROM users WHERE 1=1 AND status = ? AND 1=0"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"active"}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+// TestSelectDistinctOn tests DISTINCT ON emulation with GROUP BY and MIN
+func TestSelectDistinctOn(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Select().
+		From("orders o").
+		DistinctOn([]string{"o.userId", "o.region"}, "[o.createdAt, o]").
+		Where("o.status = ?", "paid").
+		OrderBy("o.userId").
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT o.userId, o.region, MIN([o.createdAt, o]) AS `first` FROM orders o " +
+		"WHERE o.status = $1 GROUP BY o.userId, o.region ORDER BY o.userId"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"paid"}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+// TestSelectStrictMode tests rejecting expressions with concatenated input
+func TestSelectStrictMode(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Question).StrictMode()
+
+	valid := []SelectBuilder{
+		sb.Select("name").From("users").Where("name = ?", "x'; DROP"),
+		sb.Select("name").From("users").Where("name = 'O''Brien' AND note = \"a;b\""),
+		sb.Select("`a-b`").From("users").Where("x - 1 > ? AND y / 2 < ?", 1, 2),
+		sb.Select("name").From("users").Where(Expr("status IN ?", Literals("it's", "a--b"))),
+		sb.Select("name").From("users").Where("status = 'it\\'s'"),
+	}
+	for i, b := range valid {
+		if _, _, err := b.ToN1ql(); err != nil {
+			t.Errorf("Query %d: unexpected error: %v", i, err)
+		}
+	}
+
+	invalid := []SelectBuilder{
+		sb.Select("name").From("users").Where("name = 'x'; DELETE FROM users"),
+		sb.Select("name").From("users").Where("name = 'x' -- comment"),
+		sb.Select("name").From("users").Where("name = 'x' /* comment */"),
+		sb.Select("name").From("users").Where("name = 'O'Brien'"),
+		sb.Select("name").From("users").OrderBy("name; DROP INDEX x"),
+		sb.Select("name").From("users u; DELETE FROM users"),
+	}
+	for i, b := range invalid {
+		if _, _, err := b.ToN1ql(); err == nil {
+			t.Errorf("Query %d: expected strict mode error, got nil", i)
+		}
+	}
+
+	if _, _, err := Select("name").From("users").Where("name = 'x' -- comment").ToN1ql(); err != nil {
+		t.Errorf("Unexpected error without strict mode: %v", err)
+	}
+
+	if _, _, err := Delete("users").Where("id = 1; DROP").StrictMode().ToN1ql(); err == nil {
+		t.Error("Expected strict mode error for DELETE, got nil")
+	}
+
+	if _, _, err := Update("users").Set("a", 1).Where("id = '1").StrictMode().ToN1ql(); err == nil {
+		t.Error("Expected strict mode error for UPDATE, got nil")
+	}
+}
+
+// TestSelectGroupByExpr tests GROUP BY expressions with bound args
+func TestSelectGroupByExpr(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Select("u.country").
+		Column("DATE_TRUNC_STR(u.created, ?) AS period", "month").
+		Column("COUNT(*) AS total").
+		From("users u").
+		Where("u.active = ?", true).
+		GroupBy("u.country").
+		GroupByExpr("DATE_TRUNC_STR(u.created, ?)", "month").
+		GroupByExpr(Expr("SUBSTR(u.zip, 0, ?)", 2)).
+		Having("COUNT(*) > ?", 5).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT u.country, DATE_TRUNC_STR(u.created, $1) AS period, COUNT(*) AS total FROM users u " +
+		"WHERE u.active = $2 GROUP BY u.country, DATE_TRUNC_STR(u.created, $3), SUBSTR(u.zip, 0, $4) HAVING COUNT(*) > $5"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"month", true, "month", 2, 5}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+// TestSelectHavingOrder tests that HAVING expressions keep their order and
+// that empty ones are skipped
+func TestSelectHavingOrder(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Select("u.country", "COUNT(*) AS total").
+		From("users u").
+		GroupBy("u.country").
+		Having(And{}).
+		Having("COUNT(*) > ?", 5).
+		Having(Or{Expr("SUM(u.spent) > ?", 1000), Expr("MAX(u.age) < ?", 30)}).
+		Having(Or{}).
+		Having("u.country != ?", "NL").
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT u.country, COUNT(*) AS total FROM users u GROUP BY u.country " +
+		"HAVING COUNT(*) > $1 AND (SUM(u.spent) > $2 OR MAX(u.age) < $3) AND u.country != $4"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{5, 1000, 30, "NL"}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+
+	sql, _, err = sb.Select("u.country").From("users u").GroupBy("u.country").Having(And{}).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected = "SELECT u.country FROM users u GROUP BY u.country"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+}
+
+// TestSelectCountQuery tests deriving a COUNT query for pagination
+func TestSelectCountQuery(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	page := sb.Select("u.name", "o.total").
+		From("users u").
+		Join("orders o ON o.userId = META(u).id AND o.status = ?", "paid").
+		Where("u.age > ?", 18).
+		OrderBy("u.name").
+		Limit(10).
+		Offset(20)
+
+	sql, args, err := page.CountQuery().ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "SELECT COUNT(*) FROM users u JOIN orders o ON o.userId = META(u).id AND o.status = $1 WHERE u.age > $2"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	_, pageArgs, err := page.ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	if !argsEqual(pageArgs, args) {
+		t.Errorf("Count args %v do not match page args %v", args, pageArgs)
+	}
+
+	pageSQL, _, err := page.ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	if !contains(pageSQL, "ORDER BY u.name LIMIT 10 OFFSET 20") {
+		t.Errorf("Original query was modified: %s", pageSQL)
+	}
+
+	sql, args, err = sb.Select("u.country").
+		Options("DISTINCT").
+		From("users u").
+		Where("u.age > ?", 18).
+		GroupBy("u.country").
+		Having("COUNT(*) > ?", 5).
+		OrderBy("u.country").
+		LimitExpr(Expr("?", 10)).
+		CountQuery().
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected = "SELECT COUNT(*) FROM (SELECT DISTINCT u.country FROM users u WHERE u.age > $1 GROUP BY u.country HAVING COUNT(*) > $2) AS t"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{18, 5}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+func TestSelectSelfColumn(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	tests := []struct {
+		name     string
+		builder  SelectBuilder
+		expected string
+	}{
+		{
+			name:     "Self column",
+			builder:  sb.Select().SelfColumn("b").From("bucket b"),
+			expected: "SELECT b.* FROM bucket b",
+		},
+		{
+			name:     "Self column with meta",
+			builder:  sb.Select().WithMeta("b").SelfColumn("b").From("bucket b").Where("b.type = ?", "user"),
+			expected: "SELECT META(b).id, b.* FROM bucket b WHERE b.type = $1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+		})
+	}
+}
+
+func TestSelectOrderByNulls(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, _, err := sb.Select("*").From("users").OrderBy("age DESC NULLS LAST", "name nulls first", "first").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	expected := "SELECT * FROM users ORDER BY age DESC NULLS LAST, name nulls first, first"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	for _, orderBy := range []string{"", "name NULLS", "name NULLS FIRST DESC", "name ASC DESC", "DESC", "NULLS LAST"} {
+		t.Run(orderBy, func(t *testing.T) {
+			_, _, err := sb.Select("*").From("users").OrderBy(orderBy).ToN1ql()
+			if err == nil {
+				t.Errorf("Expected error for ORDER BY %q, got nil", orderBy)
+			}
+		})
+	}
+}
+
+func TestSelectAutoGroupBy(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	tests := []struct {
+		name         string
+		builder      SelectBuilder
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name: "Non-aggregate columns are grouped",
+			builder: sb.Select("u.country", "u.city AS city", "COUNT(*) AS users", "ROUND(avg (u.age), 1) AS age").
+				From("users u").AutoGroupBy(),
+			expected: "SELECT u.country, u.city AS city, COUNT(*) AS users, ROUND(avg (u.age), 1) AS age " +
+				"FROM users u GROUP BY u.country, u.city",
+		},
+		{
+			name: "Columns with args and aliases",
+			builder: sb.Select().
+				Column("DATE_TRUNC_STR(o.created, ?) AS period", "month").
+				ColumnAs("SUM(o.total)", "revenue").
+				ColumnAs("IFMISSING(o.region, ?)", "region", "none").
+				From("orders o").
+				Where("o.status = ?", "paid").
+				AutoGroupBy(),
+			expected: "SELECT DATE_TRUNC_STR(o.created, $1) AS period, (SUM(o.total)) AS revenue, " +
+				"(IFMISSING(o.region, $2)) AS region FROM orders o WHERE o.status = $3 " +
+				"GROUP BY DATE_TRUNC_STR(o.created, $4), IFMISSING(o.region, $5)",
+			expectedArgs: []any{"month", "none", "paid", "month", "none"},
+		},
+		{
+			name:     "Explicit group by is kept",
+			builder:  sb.Select("u.country", "MAX(u.age)").From("users u").GroupBy("u.country").AutoGroupBy(),
+			expected: "SELECT u.country, MAX(u.age) FROM users u GROUP BY u.country",
+		},
+		{
+			name:     "No aggregates",
+			builder:  sb.Select("u.name", "'count(x)' AS label", "u.max_age").From("users u").AutoGroupBy(),
+			expected: "SELECT u.name, 'count(x)' AS label, u.max_age FROM users u",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+			if !argsEqual(args, tt.expectedArgs) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tt.expectedArgs, args)
+			}
+		})
+	}
+
+	_, _, err := sb.Select("u.*", "COUNT(*)").From("users u").AutoGroupBy().ToN1ql()
+	if err == nil {
+		t.Error("Expected error for grouping by a star projection, got nil")
+	}
+}
+
+func TestSelectOrderByDir(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, _, err := sb.Select("*").From("users").
+		OrderBy("status NULLS LAST").
+		OrderByDir("age", Desc).
+		OrderByDir("name", Asc).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	expected := "SELECT * FROM users ORDER BY status NULLS LAST, age DESC, name ASC"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if _, _, err := sb.Select("*").From("users").OrderByDir("age", SortDir("desc; DROP")).ToN1ql(); err == nil {
+		t.Error("Expected error for invalid sort direction, got nil")
+	}
+}
+
+func TestSelectByKeys(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	tests := []struct {
+		name         string
+		builder      SelectBuilder
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name:         "Several keys",
+			builder:      sb.SelectByKeys("users", "u1", "u2"),
+			expected:     "SELECT META(d).id AS id, d.* FROM users AS d USE KEYS [$1, $2]",
+			expectedArgs: []any{"u1", "u2"},
+		},
+		{
+			name:         "Special keyspace name",
+			builder:      sb.SelectByKeys("`travel-sample`.inventory.airline", "airline_10"),
+			expected:     "SELECT META(d).id AS id, d.* FROM `travel-sample`.inventory.airline AS d USE KEYS [$1]",
+			expectedArgs: []any{"airline_10"},
+		},
+		{
+			name:         "Further refined",
+			builder:      sb.SelectByKeys("users", "u1").Where("d.active = ?", true),
+			expected:     "SELECT META(d).id AS id, d.* FROM users AS d USE KEYS [$1] WHERE d.active = $2",
+			expectedArgs: []any{"u1", true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tt.expectedArgs, args)
+			}
+		})
+	}
+
+	sql, _, err := SelectByKeys("users", "u1").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+	if sql != "SELECT META(d).id AS id, d.* FROM users AS d USE KEYS [?]" {
+		t.Errorf("Unexpected SQL: %s", sql)
+	}
+}
+
+func TestWhereStruct(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	type address struct {
+		City string `json:"city"`
+	}
+	type userFilter struct {
+		Country  string   `json:"country,omitempty"`
+		Age      int      `json:"age"`
+		Active   *bool    `json:"active"`
+		MinScore *float64 `json:"score"`
+		Admin    bool     `json:"admin" n1qlizer:"includezero"`
+		Deleted  *string  `json:"deletedAt" n1qlizer:"includezero"`
+		Internal string   `json:"-"`
+		Nickname string
+		Address  address `json:"address"`
+		secret   string
+	}
+
+	no := false
+	tests := []struct {
+		name         string
+		builder      N1qlizer
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name:         "Non-zero fields",
+			builder:      sb.Select("*").From("users").WhereStruct(userFilter{Country: "NL", Active: &no, Internal: "x", secret: "s"}),
+			expected:     "SELECT * FROM users WHERE active = $1 AND admin = $2 AND country = $3 AND deletedAt IS NULL",
+			expectedArgs: []any{false, false, "NL"},
+		},
+		{
+			name: "Pointer to struct with other conditions",
+			builder: sb.Select("*").From("users").
+				Where("type = ?", "user").
+				WhereStruct(&userFilter{Age: 30, Nickname: "jo", Admin: true, Address: address{City: "Paris"}}),
+			expected:     "SELECT * FROM users WHERE type = $1 AND Nickname = $2 AND address = $3 AND admin = $4 AND age = $5 AND deletedAt IS NULL",
+			expectedArgs: []any{"user", "jo", address{City: "Paris"}, true, 30},
+		},
+		{
+			name:     "Nil pointer",
+			builder:  sb.Select("*").From("users").WhereStruct((*userFilter)(nil)),
+			expected: "SELECT * FROM users",
+		},
+		{
+			name:     "Nil",
+			builder:  sb.Select("*").From("x").WhereStruct(nil),
+			expected: "SELECT * FROM x",
+		},
+		{
+			name:     "Empty filter",
+			builder:  sb.Select("*").From("users").WhereStruct(struct{ Name string }{}),
+			expected: "SELECT * FROM users",
+		},
+		{
+			name:         "Update",
+			builder:      sb.Update("users").Set("active", false).WhereStruct(struct{ Country string }{"NL"}),
+			expected:     "UPDATE users SET active = $1 WHERE Country = $2",
+			expectedArgs: []any{false, "NL"},
+		},
+		{
+			name:         "Delete",
+			builder:      sb.Delete("users").WhereStruct(struct{ Country string }{"NL"}),
+			expected:     "DELETE FROM users WHERE Country = $1",
+			expectedArgs: []any{"NL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tt.expectedArgs, args)
+			}
+		})
+	}
+
+	if _, _, err := sb.Select("*").From("users").WhereStruct(map[string]any{"a": 1}).ToN1ql(); err == nil {
+		t.Error("Expected error for non-struct filter, got nil")
+	}
+}
+
+// rawPart is a N1qlizer whose ToN1ql numbers its placeholders itself, like a
+// builder with the Dollar format, and whose toN1qlRaw leaves them for the
+// enclosing query to number.
+type rawPart struct {
+	N1qlizer
+}
+
+func (r rawPart) ToN1ql() (string, []any, error) {
+	sql, args, err := r.N1qlizer.ToN1ql()
+	if err != nil {
+		return "", nil, err
+	}
+	sql, err = Dollar.ReplacePlaceholders(sql)
+	return sql, args, err
+}
+
+func (r rawPart) toN1qlRaw() (string, []any, error) {
+	return r.N1qlizer.ToN1ql()
+}
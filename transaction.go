@@ -0,0 +1,48 @@
+package n1qlizer
+
+import (
+	"fmt"
+)
+
+// Transaction statements for Couchbase N1QL transactions. Each one is a
+// N1qlizer without args and can be executed with ExecuteWith, e.g.
+//
+//	n1qlizer.ExecuteWith(db, n1qlizer.BeginWork())
+//	n1qlizer.Update("accounts").Set("balance", 10).Where("id = ?", 1).RunWith(db).Execute()
+//	n1qlizer.ExecuteWith(db, n1qlizer.CommitTransaction())
+
+// BeginWork returns a BEGIN WORK statement, starting a transaction.
+func BeginWork() N1qlizer {
+	return newPart("BEGIN WORK")
+}
+
+// SetTransaction returns a SET TRANSACTION statement setting the isolation
+// level of the current transaction to READ COMMITTED, the only level
+// Couchbase supports.
+func SetTransaction() N1qlizer {
+	return newPart("SET TRANSACTION ISOLATION LEVEL READ COMMITTED")
+}
+
+// CommitTransaction returns a COMMIT statement, committing the current
+// transaction.
+func CommitTransaction() N1qlizer {
+	return newPart("COMMIT")
+}
+
+// RollbackTransaction returns a ROLLBACK statement, rolling back the current
+// transaction.
+func RollbackTransaction() N1qlizer {
+	return newPart("ROLLBACK")
+}
+
+// Savepoint returns a SAVEPOINT statement creating the named savepoint in the
+// current transaction.
+func Savepoint(name string) N1qlizer {
+	return newPart(fmt.Sprintf("SAVEPOINT %s", name))
+}
+
+// RollbackToSavepoint returns a ROLLBACK statement rolling the current
+// transaction back to the named savepoint.
+func RollbackToSavepoint(name string) N1qlizer {
+	return newPart(fmt.Sprintf("ROLLBACK TRANSACTION TO SAVEPOINT %s", name))
+}
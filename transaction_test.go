@@ -0,0 +1,58 @@
+package n1qlizer
+
+import (
+	"testing"
+)
+
+func TestTransactionStatements(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stmt     N1qlizer
+		expected string
+	}{
+		{"BEGIN WORK", BeginWork(), "BEGIN WORK"},
+		{"SET TRANSACTION", SetTransaction(), "SET TRANSACTION ISOLATION LEVEL READ COMMITTED"},
+		{"COMMIT", CommitTransaction(), "COMMIT"},
+		{"ROLLBACK", RollbackTransaction(), "ROLLBACK"},
+		{"SAVEPOINT", Savepoint("sp1"), "SAVEPOINT sp1"},
+		{"ROLLBACK TO SAVEPOINT", RollbackToSavepoint("sp1"), "ROLLBACK TRANSACTION TO SAVEPOINT sp1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, err := tc.stmt.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build statement: %v", err)
+			}
+
+			if sql != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, sql)
+			}
+
+			if len(args) != 0 {
+				t.Errorf("Expected no args, got %v", args)
+			}
+		})
+	}
+
+	t.Run("Executes in order", func(t *testing.T) {
+		runner := NewMockRunner()
+		runner.ExpectQuery("BEGIN WORK")
+		runner.ExpectQuery("UPDATE accounts SET balance = ? WHERE id = ?", 10, 1)
+		runner.ExpectQuery("COMMIT")
+
+		if _, err := ExecuteWith(runner, BeginWork()); err != nil {
+			t.Fatalf("Failed to begin: %v", err)
+		}
+		if _, err := Update("accounts").Set("balance", 10).Where("id = ?", 1).RunWith(runner).Execute(); err != nil {
+			t.Fatalf("Failed to update: %v", err)
+		}
+		if _, err := ExecuteWith(runner, CommitTransaction()); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+
+		if err := runner.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -8,16 +8,26 @@ import (
 
 // updateData stores the state of an UPDATE query as it is built
 type updateData struct {
-	PlaceholderFormat PlaceholderFormat
-	RunWith           QueryRunner
-	Prefixes          []N1qlizer
-	Table             string
-	SetClauses        map[string]any
-	WhereParts        []N1qlizer
-	UseKeys           string
-	Limit             string
-	Offset            string
-	Suffixes          []N1qlizer
+	PlaceholderFormat       PlaceholderFormat
+	PlaceholderOffset       int
+	MaxQueryLength          int
+	FormatTimes             bool
+	Strict                  bool
+	StrictPaging            bool
+	ExpandDefaultCollection bool
+	Namespace               string
+	RunWith                 QueryRunner
+	ExtraArgs               []any
+	QueryOptions            QueryOptions
+	Prefixes                []N1qlizer
+	Table                   string
+	SetClauses              map[string]any
+	WhereParts              []N1qlizer
+	UseKeys                 N1qlizer
+	Limit                   string
+	Offset                  string
+	ReturningParts          []N1qlizer
+	Suffixes                []N1qlizer
 }
 
 func (d *updateData) ToN1ql() (sqlStr string, args []any, err error) {
@@ -26,7 +36,14 @@ func (d *updateData) ToN1ql() (sqlStr string, args []any, err error) {
 		return
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sqlStr)
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, sqlStr, d.PlaceholderOffset)
+	if err != nil {
+		return
+	}
+
+	args = append(args, d.ExtraArgs...)
+
+	err = checkQueryLength(sqlStr, d.MaxQueryLength)
 	return
 }
 
@@ -40,6 +57,17 @@ func (d *updateData) toN1qlRaw() (sqlStr string, args []any, err error) {
 		return
 	}
 
+	if d.Strict {
+		if err = checkStrict(d.strictParts()...); err != nil {
+			return
+		}
+	}
+
+	if d.StrictPaging && len(d.Offset) > 0 && len(d.Limit) == 0 {
+		err = fmt.Errorf("update statements with OFFSET must have a LIMIT")
+		return
+	}
+
 	sql := &bytes.Buffer{}
 
 	if len(d.Prefixes) > 0 {
@@ -52,11 +80,18 @@ func (d *updateData) toN1qlRaw() (sqlStr string, args []any, err error) {
 	}
 
 	sql.WriteString("UPDATE ")
-	sql.WriteString(d.Table)
+	target := d.Table
+	if d.ExpandDefaultCollection {
+		target = qualifyKeyspaces(target)
+	}
+	sql.WriteString(namespaceKeyspaces(target, d.Namespace))
 
-	if d.UseKeys != "" {
+	if d.UseKeys != nil {
 		sql.WriteString(" USE KEYS ")
-		sql.WriteString(d.UseKeys)
+		args, err = buildClauses([]N1qlizer{d.UseKeys}, sql, "", args)
+		if err != nil {
+			return
+		}
 	}
 
 	sql.WriteString(" SET ")
@@ -90,11 +125,15 @@ func (d *updateData) toN1qlRaw() (sqlStr string, args []any, err error) {
 	}
 
 	if len(d.WhereParts) > 0 {
-		sql.WriteString(" WHERE ")
-		args, err = buildClauses(d.WhereParts, sql, " AND ", args)
+		where := &bytes.Buffer{}
+		args, err = buildClauses(d.WhereParts, where, " AND ", args)
 		if err != nil {
 			return
 		}
+		if where.Len() > 0 {
+			sql.WriteString(" WHERE ")
+			sql.Write(where.Bytes())
+		}
 	}
 
 	if len(d.Limit) > 0 {
@@ -107,6 +146,14 @@ func (d *updateData) toN1qlRaw() (sqlStr string, args []any, err error) {
 		sql.WriteString(d.Offset)
 	}
 
+	if len(d.ReturningParts) > 0 {
+		sql.WriteString(" RETURNING ")
+		args, err = buildClauses(d.ReturningParts, sql, ", ", args)
+		if err != nil {
+			return
+		}
+	}
+
 	if len(d.Suffixes) > 0 {
 		sql.WriteString(" ")
 		args, err = buildClauses(d.Suffixes, sql, " ", args)
@@ -115,6 +162,10 @@ func (d *updateData) toN1qlRaw() (sqlStr string, args []any, err error) {
 		}
 	}
 
+	if d.FormatTimes {
+		args = formatTimeArgs(args)
+	}
+
 	sqlStr = sql.String()
 	return
 }
@@ -132,18 +183,73 @@ func (b UpdateBuilder) PlaceholderFormat(f PlaceholderFormat) UpdateBuilder {
 	return Set[UpdateBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
 }
 
+// StartPlaceholdersAt makes numbered placeholder formats (e.g. Dollar) start
+// numbering at n+1, for queries appended to N1QL that already uses $1..$n.
+// Formats without numbered placeholders ignore it.
+func (b UpdateBuilder) StartPlaceholdersAt(n int) UpdateBuilder {
+	return Set[UpdateBuilder, int](b, "PlaceholderOffset", n)
+}
+
+func (b UpdateBuilder) withPlaceholderOffset(n int) N1qlizer {
+	return b.StartPlaceholdersAt(n)
+}
+
+// MaxQueryLength makes ToN1ql fail if the generated N1QL is longer than n
+// bytes, catching runaway dynamic queries. Zero, the default, means unlimited.
+func (b UpdateBuilder) MaxQueryLength(n int) UpdateBuilder {
+	return Set[UpdateBuilder, int](b, "MaxQueryLength", n)
+}
+
+// FormatTimes makes the query bind time.Time and *time.Time args as RFC 3339
+// strings, the format N1QL date functions and comparisons expect. Zero times
+// and nil pointers are bound as NULL.
+func (b UpdateBuilder) FormatTimes() UpdateBuilder {
+	return Set[UpdateBuilder, bool](b, "FormatTimes", true)
+}
+
+// StrictMode makes ToN1ql fail if the N1QL of an expression passed as a
+// string looks like it has unbound user input concatenated into it: a ";", a
+// "--" or "/*" comment marker, or an unterminated quote outside of quoted
+// literals. It is a defense-in-depth check; values should still be bound
+// with placeholders.
+func (b UpdateBuilder) StrictMode() UpdateBuilder {
+	return Set[UpdateBuilder, bool](b, "Strict", true)
+}
+
+// StrictPaging makes ToN1ql fail if the query has an OFFSET but no LIMIT,
+// which is usually a paging bug and is rejected by some N1QL versions.
+func (b UpdateBuilder) StrictPaging() UpdateBuilder {
+	return Set[UpdateBuilder, bool](b, "StrictPaging", true)
+}
+
+// ExtraArgs appends args to the end of the args returned by ToN1ql and Args
+// without adding placeholders, for driver integrations that take trailing
+// parameters after the query's own. The query's placeholders and args no
+// longer line up one to one, so only pass the result to a runner that
+// expects the extra args.
+func (b UpdateBuilder) ExtraArgs(args ...any) UpdateBuilder {
+	return Extend[UpdateBuilder, any](b, "ExtraArgs", args)
+}
+
 // RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
 func (b UpdateBuilder) RunWith(runner QueryRunner) UpdateBuilder {
 	return Set[UpdateBuilder, QueryRunner](b, "RunWith", runner)
 }
 
+// WithOptions sets the QueryOptions sent with the query when it is executed,
+// replacing any set before. Runners that do not implement
+// QueryExecutorOptions ignore them.
+func (b UpdateBuilder) WithOptions(opts QueryOptions) UpdateBuilder {
+	return Set[UpdateBuilder, QueryOptions](b, "QueryOptions", opts)
+}
+
 // Execute builds and executes the query.
 func (b UpdateBuilder) Execute() (QueryResult, error) {
 	data := GetStruct(b).(updateData)
 	if data.RunWith == nil {
 		return nil, RunnerNotSet
 	}
-	return ExecuteWith(data.RunWith, b)
+	return ExecuteOptionsWith(data.RunWith, data.QueryOptions, b)
 }
 
 // ToN1ql builds the query into a N1QL string and bound args.
@@ -152,6 +258,13 @@ func (b UpdateBuilder) ToN1ql() (string, []any, error) {
 	return data.ToN1ql()
 }
 
+// ToN1qlNamed builds the query into a N1QL string using named parameters
+// ($p1, $p2, ...) and returns the bound args keyed by parameter name
+// (p1, p2, ...).
+func (b UpdateBuilder) ToN1qlNamed() (string, map[string]any, error) {
+	return toN1qlNamed(b.PlaceholderFormat(Named))
+}
+
 // MustN1ql builds the query into a N1QL string and bound args.
 //
 // MustN1ql panics if there are any errors.
@@ -163,6 +276,31 @@ func (b UpdateBuilder) MustN1ql() (string, []any) {
 	return sql, args
 }
 
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(UpdateBuilder) UpdateBuilder.
+func (b UpdateBuilder) Apply(fns ...func(UpdateBuilder) UpdateBuilder) UpdateBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b UpdateBuilder) ApplyIf(cond bool, fn func(UpdateBuilder) UpdateBuilder) UpdateBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Args returns the args the query binds, without finalizing its N1QL.
+func (b UpdateBuilder) Args() ([]any, error) {
+	data := GetStruct(b).(updateData)
+	_, args, err := data.toN1qlRaw()
+	return append(args, data.ExtraArgs...), err
+}
+
 // Prefix adds an expression to the beginning of the query
 func (b UpdateBuilder) Prefix(sql string, args ...any) UpdateBuilder {
 	return b.PrefixExpr(Expr(sql, args...))
@@ -178,9 +316,16 @@ func (b UpdateBuilder) Table(table string) UpdateBuilder {
 	return Set[UpdateBuilder, string](b, "Table", table)
 }
 
-// UseKeys sets the USE KEYS clause of the query.
-func (b UpdateBuilder) UseKeys(keys string) UpdateBuilder {
-	return Set[UpdateBuilder, string](b, "UseKeys", keys)
+// UseKeys sets the USE KEYS clause of the query. keys may contain
+// placeholders bound to args, e.g. .UseKeys("?", []string{"k1", "k2"}).
+func (b UpdateBuilder) UseKeys(keys string, args ...any) UpdateBuilder {
+	return Set[UpdateBuilder, N1qlizer](b, "UseKeys", Expr(keys, args...))
+}
+
+// ByKey restricts the query to the document with the given key, bound as an
+// arg: "USE KEYS ?".
+func (b UpdateBuilder) ByKey(key string) UpdateBuilder {
+	return b.UseKeys("?", key)
 }
 
 // Set adds SET clauses to the query.
@@ -205,9 +350,47 @@ func (b UpdateBuilder) SetMap(clauses map[string]any) UpdateBuilder {
 	return Set[UpdateBuilder, map[string]any](b, "SetClauses", data.SetClauses)
 }
 
+// SetForEach adds a SET clause that updates path in the elements of array
+// bound to variable, only those matching when if it is not empty. value is
+// spliced in if it is a N1qlizer and bound to a placeholder otherwise, and
+// args are bound to the placeholders in when, e.g.
+//
+//	.SetForEach("i.price", 10, "i", "items", "i.sku = ?", "A1")
+//
+// renders "SET i.price = ? FOR i IN items WHEN i.sku = ? END".
+func (b UpdateBuilder) SetForEach(path string, value any, variable, array, when string, args ...any) UpdateBuilder {
+	return b.Set(path, forEachValue{value: value, variable: variable, array: array, when: Expr(when, args...)})
+}
+
 // Where adds WHERE expressions to the query.
+// A nil pred adds nothing, and a bool pred adds the constant condition 1=1
+// (true) or 1=0 (false).
 func (b UpdateBuilder) Where(pred any, args ...any) UpdateBuilder {
-	return Append[UpdateBuilder, N1qlizer](b, "WhereParts", Expr(pred, args...))
+	if pred == nil {
+		return b
+	}
+	return Append[UpdateBuilder, N1qlizer](b, "WhereParts", predicate(pred, args...))
+}
+
+// WhereStruct adds an equality condition to the WHERE clause of the query
+// for each non-zero field of the struct v. See SelectBuilder.WhereStruct.
+func (b UpdateBuilder) WhereStruct(v any) UpdateBuilder {
+	return b.Where(structEq{v: v})
+}
+
+// WhereIn adds a "column IN (...)" expression to the WHERE clause of the
+// query. valuesOrSubquery may be a SelectBuilder, a slice of values or a
+// single value; see In.
+func (b UpdateBuilder) WhereIn(column string, valuesOrSubquery any) UpdateBuilder {
+	return b.Where(In(column, valuesOrSubquery))
+}
+
+// WhereCas adds a "META().cas = ?" condition to the WHERE clause of the
+// query, so that it only affects the document if it was not modified since
+// cas was read (optimistic locking). It is combined with the other WHERE
+// expressions using AND.
+func (b UpdateBuilder) WhereCas(cas any) UpdateBuilder {
+	return b.Where("META().cas = ?", cas)
 }
 
 // Limit sets a LIMIT clause on the query.
@@ -220,6 +403,15 @@ func (b UpdateBuilder) Offset(offset uint64) UpdateBuilder {
 	return Set[UpdateBuilder, string](b, "Offset", fmt.Sprintf("%d", offset))
 }
 
+// Returning adds an expression to the RETURNING clause of the query, which
+// returns values of the updated documents. Expressions are joined with commas
+// in the order they are added and may contain placeholders bound to args, e.g.
+//
+//	.Returning("META().id").Returning("ARRAY i FOR i IN items WHEN i.qty > ? END", 0)
+func (b UpdateBuilder) Returning(expr string, args ...any) UpdateBuilder {
+	return Append[UpdateBuilder, N1qlizer](b, "ReturningParts", Expr(expr, args...))
+}
+
 // Suffix adds an expression to the end of the query
 func (b UpdateBuilder) Suffix(sql string, args ...any) UpdateBuilder {
 	return b.SuffixExpr(Expr(sql, args...))
@@ -229,3 +421,52 @@ func (b UpdateBuilder) Suffix(sql string, args ...any) UpdateBuilder {
 func (b UpdateBuilder) SuffixExpr(expr N1qlizer) UpdateBuilder {
 	return Append[UpdateBuilder, N1qlizer](b, "Suffixes", expr)
 }
+
+// strictParts returns the parts of the query checked by StrictMode.
+func (d *updateData) strictParts() []N1qlizer {
+	var parts []N1qlizer
+	for _, p := range [][]N1qlizer{d.Prefixes, {d.UseKeys}, d.WhereParts, d.ReturningParts, d.Suffixes} {
+		parts = append(parts, p...)
+	}
+	return parts
+}
+
+// forEachValue renders the value of a SetForEach clause.
+type forEachValue struct {
+	value    any
+	variable string
+	array    string
+	when     N1qlizer
+}
+
+func (f forEachValue) ToN1ql() (string, []any, error) {
+	sql := &bytes.Buffer{}
+	var args []any
+
+	if n, ok := f.value.(N1qlizer); ok {
+		vsql, vargs, err := nestedToN1ql(n)
+		if err != nil {
+			return "", nil, err
+		}
+		sql.WriteString(vsql)
+		args = append(args, vargs...)
+	} else {
+		sql.WriteString("?")
+		args = append(args, f.value)
+	}
+
+	fmt.Fprintf(sql, " FOR %s IN %s", f.variable, f.array)
+
+	wsql, wargs, err := nestedToN1ql(f.when)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(wsql) > 0 {
+		sql.WriteString(" WHEN ")
+		sql.WriteString(wsql)
+		args = append(args, wargs...)
+	}
+
+	sql.WriteString(" END")
+	return sql.String(), args, nil
+}
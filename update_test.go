@@ -151,3 +151,138 @@ func TestUpdateWithExpressions(t *testing.T) {
 		t.Errorf("Wrong args: %+v", args)
 	}
 }
+
+func TestUpdateWithSubqueryExpr(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	orderCount := sb.Select("RAW COUNT(*)").
+		From("orders o").
+		Where("o.userId = META(u).id").
+		Where("o.status = ?", "paid")
+
+	sql, args, err := sb.Update("users u").
+		Set("name", "John").
+		Set("orderCount", orderCount.AsExpr()).
+		Where("u.active = ?", true).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "UPDATE users u SET name = $1, orderCount = (SELECT RAW COUNT(*) FROM orders o WHERE o.userId = META(u).id AND o.status = $2) WHERE u.active = $3"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	if len(args) != 3 || args[0] != "John" || args[1] != "paid" || args[2] != true {
+		t.Errorf("Wrong args: %+v", args)
+	}
+
+	// Scalar subqueries also work as comparison values
+	sql, args, err = Select("*").From("users").Where(Gt{"age": Select("RAW AVG(age)").From("users").AsExpr()}).ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	if sql != "SELECT * FROM users WHERE age > (SELECT RAW AVG(age) FROM users)" {
+		t.Errorf("Wrong SQL: %s", sql)
+	}
+
+	if len(args) != 0 {
+		t.Errorf("Wrong args: %+v", args)
+	}
+}
+
+// TestUpdateWhereCas tests optimistic locking on the document CAS
+func TestUpdateWhereCas(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Update("users").
+		UseKeys("'user1'").
+		Set("name", "John").
+		Where("active = ?", true).
+		WhereCas(uint64(1712345678901)).
+		ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "UPDATE users USE KEYS 'user1' SET name = $1 WHERE active = $2 AND META().cas = $3"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"John", true, uint64(1712345678901)}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+func TestUpdateByKey(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	sql, args, err := sb.Update("users").ByKey("user::1").Set("name", "John").ToN1ql()
+	if err != nil {
+		t.Fatalf("Failed to build query: %v", err)
+	}
+
+	expected := "UPDATE users USE KEYS $1 SET name = $2"
+	if sql != expected {
+		t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", expected, sql)
+	}
+
+	expectedArgs := []interface{}{"user::1", "John"}
+	if !argsEqual(expectedArgs, args) {
+		t.Errorf("Wrong args: \nExpected: %v\nGot: %v", expectedArgs, args)
+	}
+}
+
+func TestUpdateReturning(t *testing.T) {
+	sb := StatementBuilderType{builderMap: NewMap()}.PlaceholderFormat(Dollar)
+
+	tests := []struct {
+		name         string
+		builder      UpdateBuilder
+		expected     string
+		expectedArgs []any
+	}{
+		{
+			name:         "Returning updated array",
+			builder:      sb.Update("orders").SetForEach("i.price", 10, "i", "items", "i.sku = ?", "A1").ByKey("o1").Returning("items"),
+			expected:     "UPDATE orders USE KEYS $1 SET i.price = $2 FOR i IN items WHEN i.sku = $3 END RETURNING items",
+			expectedArgs: []any{"o1", 10, "A1"},
+		},
+		{
+			name: "Returning mutated elements with args",
+			builder: sb.Update("orders o").
+				SetForEach("i.qty", Expr("i.qty - ?", 1), "i", "o.items", "").
+				Where("o.status = ?", "open").
+				Returning("META(o).id").
+				Returning("ARRAY i FOR i IN o.items WHEN i.qty < ? END", 5),
+			expected: "UPDATE orders o SET i.qty = i.qty - $1 FOR i IN o.items END WHERE o.status = $2 " +
+				"RETURNING META(o).id, ARRAY i FOR i IN o.items WHEN i.qty < $3 END",
+			expectedArgs: []any{1, "open", 5},
+		},
+		{
+			name:         "Returning self",
+			builder:      sb.Update("users").Set("active", true).Where("age > ?", 65).Returning("META().id, self"),
+			expected:     "UPDATE users SET active = $1 WHERE age > $2 RETURNING META().id, self",
+			expectedArgs: []any{true, 65},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.builder.ToN1ql()
+			if err != nil {
+				t.Fatalf("Failed to build query: %v", err)
+			}
+			if sql != tt.expected {
+				t.Errorf("Wrong SQL: \nExpected: %s\nGot: %s", tt.expected, sql)
+			}
+			if !argsEqual(tt.expectedArgs, args) {
+				t.Errorf("Wrong args: \nExpected: %v\nGot: %v", tt.expectedArgs, args)
+			}
+		})
+	}
+}
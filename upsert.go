@@ -8,17 +8,26 @@ import (
 
 // upsertData stores the state of an UPSERT query as it is built
 type upsertData struct {
-	PlaceholderFormat PlaceholderFormat
-	RunWith           QueryRunner
-	Prefixes          []N1qlizer
-	Options           []string
-	Into              string
-	Key               string
-	Value             any
-	Columns           []string
-	Values            [][]any
-	Suffixes          []N1qlizer
-	SetMap            map[string]any
+	PlaceholderFormat       PlaceholderFormat
+	PlaceholderOffset       int
+	MaxQueryLength          int
+	FormatTimes             bool
+	ExpandDefaultCollection bool
+	Namespace               string
+	RunWith                 QueryRunner
+	ExtraArgs               []any
+	QueryOptions            QueryOptions
+	Prefixes                []N1qlizer
+	Options                 []string
+	Into                    string
+	Key                     string
+	Value                   any
+	Columns                 []string
+	Values                  [][]any
+	Expiration              int
+	KeyValueSelect          N1qlizer
+	Suffixes                []N1qlizer
+	SetMap                  map[string]any
 }
 
 func (d *upsertData) ToN1ql() (sqlStr string, args []any, err error) {
@@ -27,7 +36,14 @@ func (d *upsertData) ToN1ql() (sqlStr string, args []any, err error) {
 		return
 	}
 
-	sqlStr, err = d.PlaceholderFormat.ReplacePlaceholders(sqlStr)
+	sqlStr, err = replacePlaceholders(d.PlaceholderFormat, sqlStr, d.PlaceholderOffset)
+	if err != nil {
+		return
+	}
+
+	args = append(args, d.ExtraArgs...)
+
+	err = checkQueryLength(sqlStr, d.MaxQueryLength)
 	return
 }
 
@@ -56,12 +72,32 @@ func (d *upsertData) toN1qlRaw() (sqlStr string, args []any, err error) {
 	}
 
 	sql.WriteString("INTO ")
-	sql.WriteString(d.Into)
+	target := d.Into
+	if d.ExpandDefaultCollection {
+		target = qualifyKeyspaces(target)
+	}
+	sql.WriteString(namespaceKeyspaces(target, d.Namespace))
+
+	if d.KeyValueSelect != nil {
+		if d.Key != "" || len(d.Columns) > 0 || len(d.Values) > 0 || len(d.SetMap) > 0 || d.Expiration > 0 {
+			return "", nil, fmt.Errorf("upsert statements cannot combine KeyValueSelect with other values or an expiration")
+		}
+
+		sql.WriteString(" ")
+		args, err = buildClauses([]N1qlizer{d.KeyValueSelect}, sql, "", args)
+		if err != nil {
+			return
+		}
+	}
 
 	// Couchbase's UPSERT has a special syntax for keys and values
 	if d.Key != "" && d.Value != nil {
 		// UPSERT INTO bucket (KEY, VALUE) VALUES ("key1", {"field": "value"})
-		sql.WriteString(" (KEY, VALUE) VALUES (")
+		if d.Expiration > 0 {
+			sql.WriteString(" (KEY, VALUE, OPTIONS) VALUES (")
+		} else {
+			sql.WriteString(" (KEY, VALUE) VALUES (")
+		}
 		if strings.HasPrefix(d.Key, "?") {
 			sql.WriteString(d.Key)
 			args = append(args, d.Key[1:]) // Assuming ? is a placeholder
@@ -82,11 +118,18 @@ func (d *upsertData) toN1qlRaw() (sqlStr string, args []any, err error) {
 			sql.WriteString("?")
 			args = append(args, d.Value)
 		}
+		if d.Expiration > 0 {
+			sql.WriteString(", ")
+			sql.WriteString(expirationOption(d.Expiration))
+		}
 		sql.WriteString(")")
 	} else if len(d.Columns) > 0 && len(d.Values) > 0 {
 		// Standard INSERT-like syntax
 		sql.WriteString(" (")
 		sql.WriteString(strings.Join(d.Columns, ", "))
+		if d.Expiration > 0 {
+			sql.WriteString(", OPTIONS")
+		}
 		sql.WriteString(")")
 
 		sql.WriteString(" VALUES ")
@@ -107,10 +150,15 @@ func (d *upsertData) toN1qlRaw() (sqlStr string, args []any, err error) {
 					args = append(args, value)
 				}
 			}
+			if d.Expiration > 0 {
+				valueStrings = append(valueStrings, expirationOption(d.Expiration))
+			}
 			valuesStrings[i] = fmt.Sprintf("(%s)", strings.Join(valueStrings, ", "))
 		}
 
 		sql.WriteString(strings.Join(valuesStrings, ", "))
+	} else if d.Expiration > 0 {
+		return "", nil, fmt.Errorf("upsert statements must use Document or Columns and Values to set an expiration")
 	} else if len(d.SetMap) > 0 {
 		// Use SET for individual fields
 		sql.WriteString(" SET ")
@@ -140,6 +188,10 @@ func (d *upsertData) toN1qlRaw() (sqlStr string, args []any, err error) {
 		}
 	}
 
+	if d.FormatTimes {
+		args = formatTimeArgs(args)
+	}
+
 	sqlStr = sql.String()
 	return
 }
@@ -157,18 +209,58 @@ func (b UpsertBuilder) PlaceholderFormat(f PlaceholderFormat) UpsertBuilder {
 	return Set[UpsertBuilder, PlaceholderFormat](b, "PlaceholderFormat", f)
 }
 
+// StartPlaceholdersAt makes numbered placeholder formats (e.g. Dollar) start
+// numbering at n+1, for queries appended to N1QL that already uses $1..$n.
+// Formats without numbered placeholders ignore it.
+func (b UpsertBuilder) StartPlaceholdersAt(n int) UpsertBuilder {
+	return Set[UpsertBuilder, int](b, "PlaceholderOffset", n)
+}
+
+func (b UpsertBuilder) withPlaceholderOffset(n int) N1qlizer {
+	return b.StartPlaceholdersAt(n)
+}
+
+// MaxQueryLength makes ToN1ql fail if the generated N1QL is longer than n
+// bytes, catching runaway dynamic queries. Zero, the default, means unlimited.
+func (b UpsertBuilder) MaxQueryLength(n int) UpsertBuilder {
+	return Set[UpsertBuilder, int](b, "MaxQueryLength", n)
+}
+
+// FormatTimes makes the query bind time.Time and *time.Time args as RFC 3339
+// strings, the format N1QL date functions and comparisons expect. Zero times
+// and nil pointers are bound as NULL.
+func (b UpsertBuilder) FormatTimes() UpsertBuilder {
+	return Set[UpsertBuilder, bool](b, "FormatTimes", true)
+}
+
+// ExtraArgs appends args to the end of the args returned by ToN1ql and Args
+// without adding placeholders, for driver integrations that take trailing
+// parameters after the query's own. The query's placeholders and args no
+// longer line up one to one, so only pass the result to a runner that
+// expects the extra args.
+func (b UpsertBuilder) ExtraArgs(args ...any) UpsertBuilder {
+	return Extend[UpsertBuilder, any](b, "ExtraArgs", args)
+}
+
 // RunWith sets a Runner (like a Couchbase DB connection) to be used with e.g. Execute.
 func (b UpsertBuilder) RunWith(runner QueryRunner) UpsertBuilder {
 	return Set[UpsertBuilder, QueryRunner](b, "RunWith", runner)
 }
 
+// WithOptions sets the QueryOptions sent with the query when it is executed,
+// replacing any set before. Runners that do not implement
+// QueryExecutorOptions ignore them.
+func (b UpsertBuilder) WithOptions(opts QueryOptions) UpsertBuilder {
+	return Set[UpsertBuilder, QueryOptions](b, "QueryOptions", opts)
+}
+
 // Execute builds and executes the query.
 func (b UpsertBuilder) Execute() (QueryResult, error) {
 	data := GetStruct(b).(upsertData)
 	if data.RunWith == nil {
 		return nil, RunnerNotSet
 	}
-	return ExecuteWith(data.RunWith, b)
+	return ExecuteOptionsWith(data.RunWith, data.QueryOptions, b)
 }
 
 // ToN1ql builds the query into a N1QL string and bound args.
@@ -177,6 +269,13 @@ func (b UpsertBuilder) ToN1ql() (string, []any, error) {
 	return data.ToN1ql()
 }
 
+// ToN1qlNamed builds the query into a N1QL string using named parameters
+// ($p1, $p2, ...) and returns the bound args keyed by parameter name
+// (p1, p2, ...).
+func (b UpsertBuilder) ToN1qlNamed() (string, map[string]any, error) {
+	return toN1qlNamed(b.PlaceholderFormat(Named))
+}
+
 // MustN1ql builds the query into a N1QL string and bound args.
 //
 // MustN1ql panics if there are any errors.
@@ -188,6 +287,31 @@ func (b UpsertBuilder) MustN1ql() (string, []any) {
 	return sql, args
 }
 
+// Apply passes the builder through each of fns in order, e.g. to reuse
+// query fragments written as func(UpsertBuilder) UpsertBuilder.
+func (b UpsertBuilder) Apply(fns ...func(UpsertBuilder) UpsertBuilder) UpsertBuilder {
+	for _, fn := range fns {
+		b = fn(b)
+	}
+	return b
+}
+
+// ApplyIf passes the builder through fn only if cond is true, keeping
+// conditional clauses in the fluent chain.
+func (b UpsertBuilder) ApplyIf(cond bool, fn func(UpsertBuilder) UpsertBuilder) UpsertBuilder {
+	if !cond {
+		return b
+	}
+	return fn(b)
+}
+
+// Args returns the args the query binds, without finalizing its N1QL.
+func (b UpsertBuilder) Args() ([]any, error) {
+	data := GetStruct(b).(upsertData)
+	_, args, err := data.toN1qlRaw()
+	return append(args, data.ExtraArgs...), err
+}
+
 // Prefix adds an expression to the beginning of the query
 func (b UpsertBuilder) Prefix(sql string, args ...any) UpsertBuilder {
 	return b.PrefixExpr(Expr(sql, args...))
@@ -232,6 +356,23 @@ func (b UpsertBuilder) Values(values ...any) UpsertBuilder {
 	return Set[UpsertBuilder, [][]any](b, "Values", data.Values)
 }
 
+// KeyValueSelect upserts the documents returned by query, with their keys and
+// values given by expressions over the query's result, e.g.
+//
+//	.KeyValueSelect("k", "v", Select("META(u).id AS k", "u AS v").From("users u"))
+//
+// renders "UPSERT INTO b (KEY k, VALUE v) SELECT META(u).id AS k, u AS v FROM users u".
+func (b UpsertBuilder) KeyValueSelect(keyExpr, valueExpr string, query SelectBuilder) UpsertBuilder {
+	return Set[UpsertBuilder, N1qlizer](b, "KeyValueSelect", keyValueSelect{key: keyExpr, value: valueExpr, query: query})
+}
+
+// WithExpiry sets the expiration (TTL) of the upserted documents in seconds.
+// It is rendered as an OPTIONS column, e.g.
+// "UPSERT INTO b (KEY, VALUE, OPTIONS) VALUES (?, ?, {"expiration": 3600})".
+func (b UpsertBuilder) WithExpiry(seconds int) UpsertBuilder {
+	return Set[UpsertBuilder, int](b, "Expiration", seconds)
+}
+
 // SetMap adds key-value pairs to set rather than a list of values.
 func (b UpsertBuilder) SetMap(clauses map[string]any) UpsertBuilder {
 	return Set[UpsertBuilder, map[string]any](b, "SetMap", clauses)
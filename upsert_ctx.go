@@ -15,7 +15,7 @@ func (d *upsertData) ExecuteContext(ctx context.Context) (QueryResult, error) {
 		return nil, RunnerNotQueryRunnerContext
 	}
 
-	return ExecuteContextWith(ctx, runner, d)
+	return ExecuteContextOptionsWith(ctx, runner, d.QueryOptions, d)
 }
 
 // ExecuteContext builds and executes the query with the context and runner set by RunWith.